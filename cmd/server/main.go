@@ -3,17 +3,30 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"order-status-service/internal/config"
 	"order-status-service/internal/controller"
+	"order-status-service/internal/database"
+	"order-status-service/internal/events"
+	"order-status-service/internal/middleware"
+	"order-status-service/internal/migration"
 	"order-status-service/internal/repository"
 	"order-status-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
@@ -21,46 +34,180 @@ func main() {
 		log.Println("⚠️ No .env file found")
 	}
 
-	mongoURI := os.Getenv("MONGO_URI")
-	dbName := os.Getenv("MONGO_DB")
+	// Defaults < archivo (--config / CONFIG_FILE) < variables de entorno < flags
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	if err := run(cfg); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Println("👋 Order Status Service stopped")
+}
+
+func run(cfg *config.Config) error {
+	// SIGINT/SIGTERM inician el apagado ordenado
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Contexto raíz de los workers y del trabajo en segundo plano. Se cancela recién después de
+	// drenar las peticiones HTTP, para que los eventos que generen sigan procesándose.
+	rootCtx, cancelRoot := context.WithCancel(context.Background())
+	defer cancelRoot()
 
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+	client, err := database.Connect(signalCtx, cfg.Mongo)
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to MongoDB: %v", err)
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Disconnect(ctx); err != nil {
+			log.Printf("⚠️ Error disconnecting from MongoDB: %v", err)
+		}
+	}()
 
-	db := client.Database(dbName)
+	db := client.Database(cfg.Mongo.Database)
+
+	// Migraciones de esquema (índices, etc.) antes de atender peticiones
+	if err := migration.NewRunner(db).Run(signalCtx, migration.All()); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
 
 	// Inicializamos Gin y servicios base
 	router := gin.Default()
-	authService := service.NewAuthService()
+	// Primero: responde los errores que registren los demás middlewares y los controladores
+	router.Use(middleware.ErrorHandler())
+	router.Use(middleware.Locale())
+	authService := service.NewAuthService(cfg.Auth)
+	serviceAuthService := service.NewServiceAuthService(cfg.ServiceAuth)
+	if !serviceAuthService.Enabled() {
+		log.Println("⚠️ No service credentials configured: POST /status/init will reject every call")
+	}
 
 	// Repositorios
 	catalogRepo := repository.NewCatalogRepository(db)
 	orderRepo := repository.NewOrderStatusRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	orderSyncRepo := repository.NewOrderSyncRepository(db)
 
 	// Servicios
-	catalogService := service.NewCatalogService(catalogRepo)
-	orderStatusService := service.NewOrderStatusService(orderRepo)
-	catalogAdminService := service.NewCatalogAdminService(catalogRepo)
+	catalogService := service.NewCatalogService(catalogRepo, cfg.Timeouts)
+	webhookService := service.NewWebhookService(webhookRepo, catalogRepo, cfg.Webhooks, cfg.Timeouts)
+	statusBroker := service.NewStatusBroker()
+	ordersClient := service.NewOrdersClient(cfg.Orders)
+	orderSyncService := service.NewOrderSyncService(orderSyncRepo, catalogRepo, ordersClient, cfg.OrderSync, cfg.Timeouts)
+	orderStatusService := service.NewOrderStatusService(orderRepo, catalogRepo, statusBroker, ordersClient, cfg.Timeouts)
+	catalogAdminService := service.NewCatalogAdminService(rootCtx, catalogRepo, orderRepo, cfg.Timeouts)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency, cfg.Timeouts)
 
 	// Precargar estados base (solo si no existen)
-	if err := catalogService.SeedDefaultStatuses(); err != nil {
+	if err := catalogService.SeedDefaultStatuses(signalCtx); err != nil {
 		log.Printf("⚠️ Error al crear estados base: %v", err)
 	}
 
+	var workers sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(rootCtx)
+		}()
+	}
+
+	// Relay del outbox: publica los eventos de cambio de estado y crea las entregas de webhooks
+	// y los avisos al servicio de órdenes
+	startWorker(events.NewRelay(outboxRepo, events.NewFanout(events.NewLogPublisher(), webhookService, orderSyncService), cfg.Relay).Run)
+
+	// Worker de entregas de webhooks
+	startWorker(webhookService.Run)
+
+	// Worker de avisos al servicio de órdenes (órdenes canceladas, rechazadas o entregadas)
+	startWorker(orderSyncService.Run)
+
+	// Refresco periódico del JWKS (solo con AUTH_MODE=jwks)
+	startWorker(authService.Run)
+
 	// Controladores
-	controller.NewOrderStatusController(router, orderStatusService, authService)
+	controller.NewOrderStatusController(router, orderStatusService, authService, idempotencyService, serviceAuthService)
 	controller.NewCatalogAdminController(router, catalogAdminService, authService)
+	controller.NewWebhookAdminController(router, webhookService, authService)
+	controller.NewOrderSyncAdminController(router, orderSyncService, authService)
+	controller.NewAuthAdminController(router, authService)
+
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: router}
+	// Shutdown no espera a las conexiones SSE: cerrar el broker termina los streams abiertos
+	server.RegisterOnShutdown(statusBroker.Close)
+
+	serverErr := make(chan error, 1)
+	if cfg.TLS.Enabled() {
+		tlsConfig, err := serverTLSConfig(cfg.TLS)
+		if err != nil {
+			return fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		server.TLSConfig = tlsConfig
+		log.Printf("🚀 Order Status Service running on port %s (TLS)", cfg.Port)
+		go func() { serverErr <- server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile) }()
+	} else {
+		log.Printf("🚀 Order Status Service running on port %s", cfg.Port)
+		go func() { serverErr <- server.ListenAndServe() }()
+	}
+
+	select {
+	case err := <-serverErr:
+		cancelRoot()
+		workers.Wait()
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-signalCtx.Done():
+	}
+	stop() // una segunda señal termina el proceso de inmediato
+
+	log.Printf("🛑 Shutting down (draining requests for up to %s)", cfg.Server.ShutdownTimeout)
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelDrain()
 
-	// Puerto
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8082"
+	if err := server.Shutdown(drainCtx); err != nil {
+		log.Printf("⚠️ HTTP shutdown incomplete: %v", err)
 	}
 
-	log.Printf("🚀 Order Status Service running on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Con las peticiones terminadas se detienen los workers, dentro del mismo plazo
+	cancelRoot()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-drainCtx.Done():
+		log.Println("⚠️ Background workers did not stop before the shutdown timeout")
+	}
+	return nil
+}
+
+// Con una CA de clientes configurada se pide certificado cliente (mTLS) sin exigirlo,
+// para que los usuarios sigan entrando con su token y los servicios puedan autenticarse con certificado
+func serverTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in client CA file")
 	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return tlsConfig, nil
 }
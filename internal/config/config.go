@@ -1,27 +1,125 @@
 // config.go
 package config
 
-import "os"
+import (
+	"time"
+)
 
+// Config es la configuración completa del servicio. Se arma en capas, de menor a mayor prioridad:
+// valores por defecto, archivo YAML/TOML opcional, variables de entorno y flags de línea de comandos.
 type Config struct {
-	MongoURI  string
-	AuthURL   string
-	OrdersURL string
-	Port      string
-}
-
-func Load() *Config {
-	return &Config{
-		MongoURI:  getEnv("MONGO_URI", "mongodb://host.docker.internal:27017"),
-		AuthURL:   getEnv("AUTH_URL", "http://host.docker.internal:3000"),
-		OrdersURL: getEnv("ORDERS_URL", "http://host.docker.internal:3004"),
-		Port:      getEnv("PORT", "8080"),
-	}
-}
-
-func getEnv(key, fallback string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
-	}
-	return fallback
+	Port        string
+	Server      ServerConfig
+	Mongo       MongoConfig
+	Timeouts    TimeoutsConfig
+	Idempotency IdempotencyConfig
+	Orders      OrdersConfig
+	Auth        AuthConfig
+	ServiceAuth ServiceAuthConfig
+	TLS         TLSConfig
+	Relay       WorkerConfig
+	OrderSync   WorkerConfig
+	Webhooks    WebhookConfig
+}
+
+type ServerConfig struct {
+	// Tiempo máximo para terminar las peticiones en curso al recibir SIGINT/SIGTERM
+	ShutdownTimeout time.Duration
+}
+
+type MongoConfig struct {
+	URI      string
+	Database string
+
+	// Al iniciar se hace ping hasta ConnectAttempts veces, con backoff exponencial desde RetryBackoff
+	ConnectTimeout  time.Duration // timeout de cada ping
+	ConnectAttempts int
+	RetryBackoff    time.Duration
+}
+
+// Plazos de cada operación contra MongoDB. Se aplican sobre el contexto de la request,
+// por lo que también se cancelan si el cliente se desconecta antes.
+type TimeoutsConfig struct {
+	Read  time.Duration // consultas
+	Write time.Duration // altas y cambios (transacciones incluidas)
+	Bulk  time.Duration // actualizaciones masivas en segundo plano (p. ej. propagar un renombre)
+}
+
+// Claves de idempotencia (cabecera Idempotency-Key)
+type IdempotencyConfig struct {
+	// Cuánto queda reservada una clave mientras se procesa su petición. Si el proceso se cae
+	// a mitad de camino, pasado este plazo otra petición con la misma clave puede tomarla.
+	Lease time.Duration
+}
+
+// Modos de validación de las órdenes contra el microservicio de órdenes
+const (
+	OrdersValidationStrict  = "strict"  // si el servicio no responde, se rechaza la orden
+	OrdersValidationLenient = "lenient" // si el servicio no responde, se acepta la orden sin validar
+)
+
+// Microservicio de órdenes
+type OrdersConfig struct {
+	URL        string
+	Validation string        // strict | lenient
+	Timeout    time.Duration // timeout de cada intento
+
+	// Reintentos ante errores de red, 429 y 5xx, con backoff exponencial desde RetryBackoff
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	// Tras BreakerThreshold fallas seguidas no se llama al servicio durante BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// Validación de los tokens de usuario
+type AuthConfig struct {
+	URL            string        // servicio de autenticación
+	Mode           string        // remote (GET /users/current) | jwks (verificación local)
+	JWKSURL        string        // por defecto <URL>/.well-known/jwks.json
+	Audience       string        // aud requerido (obligatorio en modo jwks)
+	Issuer         string        // iss requerido en modo jwks (vacío: no se valida)
+	JWKSRefresh    time.Duration // cada cuánto se vuelve a descargar el JWKS
+	RemoteFallback bool          // en modo jwks, usar el modo remoto si el JWKS no está disponible
+	Timeout        time.Duration // timeout de las llamadas al servicio de autenticación
+
+	// Caché de validaciones de tokens (CacheSize 0 la desactiva)
+	CacheSize        int
+	CacheTTL         time.Duration // tokens válidos
+	CacheNegativeTTL time.Duration // tokens rechazados
+}
+
+// Credenciales de los servicios que pueden llamar a las rutas internas (p. ej. /status/init)
+type ServiceAuthConfig struct {
+	APIKeys      map[string]string // servicio -> API key
+	HMACSecrets  map[string]string // servicio -> secreto compartido
+	MTLSNames    []string          // CN de los certificados cliente aceptados
+	MaxClockSkew time.Duration     // diferencia máxima aceptada en el timestamp firmado
+}
+
+// Si CertFile y KeyFile están definidos el servidor atiende por HTTPS;
+// con ClientCAFile además pide (opcionalmente) certificado cliente para mTLS
+type TLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// Parámetros de un worker que procesa una cola con reintentos (relay del outbox, webhooks)
+type WorkerConfig struct {
+	PollInterval time.Duration // espera cuando la cola está vacía
+	Lease        time.Duration // cuánto tiempo queda tomado un elemento en proceso
+	MaxAttempts  int           // intentos antes de marcarlo como fallido
+	BaseBackoff  time.Duration // espera antes del primer reintento (se duplica en cada uno)
+	MaxBackoff   time.Duration
+}
+
+type WebhookConfig struct {
+	WorkerConfig
+	Timeout time.Duration // timeout de cada entrega
 }
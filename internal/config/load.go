@@ -0,0 +1,180 @@
+// load.go
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ValidationError lista todos los valores faltantes o inválidos de la configuración
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load arma la configuración a partir de (en orden de prioridad creciente) los valores por defecto,
+// el archivo indicado con --config o CONFIG_FILE (YAML o TOML), las variables de entorno y los flags.
+// Si algún valor falta o es inválido devuelve un *ValidationError con todos los problemas.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("order-status-service", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file")
+	flags := map[string]string{} // nombre del flag -> clave
+	for _, s := range settings {
+		fs.String(flagName(s.key), "", s.usage)
+		flags[flagName(s.key)] = s.key
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	sources := map[string]string{}
+	set := func(key, value, source string) {
+		values[key] = value
+		sources[key] = source
+	}
+
+	for _, s := range settings {
+		if s.def != "" {
+			set(s.key, s.def, "default")
+		}
+	}
+
+	if *configFile != "" {
+		fileValues, err := readFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileValues {
+			if value != "" {
+				set(key, value, *configFile)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		for _, env := range s.env {
+			// una variable vacía cuenta como no definida
+			if value := os.Getenv(env); value != "" {
+				set(s.key, value, "$"+env)
+				break
+			}
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := flags[f.Name]; ok {
+			set(key, f.Value.String(), "--"+f.Name)
+		}
+	})
+
+	cfg := &Config{}
+	var problems []string
+	invalid := map[string]bool{}
+	for _, s := range settings {
+		value, ok := values[s.key]
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.set(cfg, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s (from %s): %v", s.key, sources[s.key], err))
+			invalid[s.key] = true
+		}
+	}
+	if cfg.Auth.JWKSURL == "" && cfg.Auth.URL != "" {
+		cfg.Auth.JWKSURL = strings.TrimSuffix(cfg.Auth.URL, "/") + "/.well-known/jwks.json"
+	}
+
+	problems = append(problems, cfg.validate(invalid)...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// Lee el archivo de configuración y lo aplana a claves "seccion.opcion"
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension '%s' (use .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, s := range settings {
+		known[s.key] = true
+	}
+
+	values := map[string]string{}
+	var unknown []string
+	flatten("", raw, known, values, &unknown)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("unknown keys in %s: %s", path, strings.Join(unknown, ", "))}}
+	}
+	return values, nil
+}
+
+func flatten(prefix string, node map[string]interface{}, known map[string]bool, values map[string]string, unknown *[]string) {
+	for name, value := range node {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if known[key] {
+			values[key] = stringify(value)
+			continue
+		}
+		if section, ok := value.(map[string]interface{}); ok {
+			flatten(key, section, known, values, unknown)
+			continue
+		}
+		*unknown = append(*unknown, key)
+	}
+}
+
+// Convierte un valor del archivo al mismo formato que las variables de entorno:
+// listas como "a,b" y mapas como "k=v,k2=v2"
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, stringify(item))
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		items := make([]string, 0, len(v))
+		for name, item := range v {
+			items = append(items, name+"="+stringify(item))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
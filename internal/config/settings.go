@@ -0,0 +1,217 @@
+// settings.go
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Una opción de configuración y de dónde puede leerse
+type setting struct {
+	key   string   // clave en el archivo (secciones separadas por punto); el flag es la misma clave con guiones
+	env   []string // variables de entorno, en orden de prioridad (las siguientes son alias)
+	def   string   // valor por defecto
+	usage string
+	set   func(c *Config, value string) error
+}
+
+// Todas las opciones del servicio
+var settings = []setting{
+	{key: "port", env: []string{"PORT"}, def: "8080", usage: "HTTP port",
+		set: stringVar(func(c *Config) *string { return &c.Port })},
+	{key: "server.shutdown_timeout", env: []string{"SHUTDOWN_TIMEOUT"}, def: "15s", usage: "time to drain in-flight requests on shutdown",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+
+	{key: "mongo.uri", env: []string{"MONGO_URI"}, def: "mongodb://host.docker.internal:27017", usage: "MongoDB connection string",
+		set: stringVar(func(c *Config) *string { return &c.Mongo.URI })},
+	{key: "mongo.database", env: []string{"MONGO_DB", "MONGO_DATABASE"}, usage: "MongoDB database name",
+		set: stringVar(func(c *Config) *string { return &c.Mongo.Database })},
+	{key: "mongo.connect_timeout", env: []string{"MONGO_CONNECT_TIMEOUT"}, def: "5s", usage: "timeout of each startup ping",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Mongo.ConnectTimeout })},
+	{key: "mongo.connect_attempts", env: []string{"MONGO_CONNECT_ATTEMPTS"}, def: "5", usage: "startup ping attempts",
+		set: intVar(func(c *Config) *int { return &c.Mongo.ConnectAttempts })},
+	{key: "mongo.retry_backoff", env: []string{"MONGO_RETRY_BACKOFF"}, def: "1s", usage: "wait before the first ping retry (doubles each time)",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Mongo.RetryBackoff })},
+
+	{key: "timeouts.read", env: []string{"READ_TIMEOUT"}, def: "5s", usage: "deadline of read operations",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Timeouts.Read })},
+	{key: "timeouts.write", env: []string{"WRITE_TIMEOUT"}, def: "10s", usage: "deadline of write operations",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Timeouts.Write })},
+	{key: "timeouts.bulk", env: []string{"BULK_TIMEOUT"}, def: "5m", usage: "deadline of bulk background updates",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Timeouts.Bulk })},
+
+	{key: "idempotency.lease", env: []string{"IDEMPOTENCY_LEASE"}, def: "1m", usage: "how long an in-progress Idempotency-Key stays locked",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Idempotency.Lease })},
+
+	{key: "orders.url", env: []string{"ORDERS_SERVICE_URL", "ORDERS_URL"}, def: "http://host.docker.internal:3004", usage: "orders service base URL",
+		set: stringVar(func(c *Config) *string { return &c.Orders.URL })},
+	{key: "orders.validation", env: []string{"ORDERS_VALIDATION"}, def: "lenient", usage: "order validation mode: strict | lenient",
+		set: stringVar(func(c *Config) *string { return &c.Orders.Validation })},
+	{key: "orders.timeout", env: []string{"ORDERS_TIMEOUT"}, def: "3s", usage: "timeout of each orders service request",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Orders.Timeout })},
+	{key: "orders.max_attempts", env: []string{"ORDERS_MAX_ATTEMPTS"}, def: "3", usage: "orders service request attempts",
+		set: intVar(func(c *Config) *int { return &c.Orders.MaxAttempts })},
+	{key: "orders.retry_backoff", env: []string{"ORDERS_RETRY_BACKOFF"}, def: "200ms", usage: "wait before the first orders service retry (doubles each time)",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Orders.RetryBackoff })},
+	{key: "orders.breaker_threshold", env: []string{"ORDERS_BREAKER_THRESHOLD"}, def: "5", usage: "consecutive failures that open the orders service circuit breaker",
+		set: intVar(func(c *Config) *int { return &c.Orders.BreakerThreshold })},
+	{key: "orders.breaker_cooldown", env: []string{"ORDERS_BREAKER_COOLDOWN"}, def: "30s", usage: "time the orders service circuit breaker stays open",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Orders.BreakerCooldown })},
+
+	{key: "auth.url", env: []string{"AUTH_SERVICE_URL", "AUTH_URL"}, def: "http://host.docker.internal:3000", usage: "auth service base URL",
+		set: stringVar(func(c *Config) *string { return &c.Auth.URL })},
+	{key: "auth.mode", env: []string{"AUTH_MODE"}, def: "remote", usage: "token validation mode: remote | jwks",
+		set: stringVar(func(c *Config) *string { return &c.Auth.Mode })},
+	{key: "auth.jwks_url", env: []string{"AUTH_JWKS_URL"}, usage: "JWKS URL (default <auth.url>/.well-known/jwks.json)",
+		set: stringVar(func(c *Config) *string { return &c.Auth.JWKSURL })},
+	{key: "auth.audience", env: []string{"AUTH_AUDIENCE"}, usage: "required token audience in jwks mode",
+		set: stringVar(func(c *Config) *string { return &c.Auth.Audience })},
+	{key: "auth.issuer", env: []string{"AUTH_ISSUER"}, usage: "required token issuer in jwks mode",
+		set: stringVar(func(c *Config) *string { return &c.Auth.Issuer })},
+	{key: "auth.jwks_refresh", env: []string{"AUTH_JWKS_REFRESH"}, def: "10m", usage: "JWKS refresh interval",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Auth.JWKSRefresh })},
+	{key: "auth.remote_fallback", env: []string{"AUTH_REMOTE_FALLBACK"}, def: "true", usage: "fall back to remote validation when the JWKS is unavailable",
+		set: boolVar(func(c *Config) *bool { return &c.Auth.RemoteFallback })},
+	{key: "auth.timeout", env: []string{"AUTH_TIMEOUT"}, def: "5s", usage: "auth service request timeout",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Auth.Timeout })},
+	{key: "auth.cache_size", env: []string{"AUTH_CACHE_SIZE"}, def: "10000", usage: "token cache capacity (0 disables it)",
+		set: intVar(func(c *Config) *int { return &c.Auth.CacheSize })},
+	{key: "auth.cache_ttl", env: []string{"AUTH_CACHE_TTL"}, def: "1m", usage: "TTL of valid tokens in the cache",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Auth.CacheTTL })},
+	{key: "auth.cache_negative_ttl", env: []string{"AUTH_CACHE_NEGATIVE_TTL"}, def: "10s", usage: "TTL of rejected tokens in the cache",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Auth.CacheNegativeTTL })},
+
+	{key: "service_auth.api_keys", env: []string{"SERVICE_API_KEYS"}, usage: "service API keys: name=key,...",
+		set: mapVar(func(c *Config) *map[string]string { return &c.ServiceAuth.APIKeys })},
+	{key: "service_auth.hmac_secrets", env: []string{"SERVICE_HMAC_SECRETS"}, usage: "service HMAC secrets: name=secret,...",
+		set: mapVar(func(c *Config) *map[string]string { return &c.ServiceAuth.HMACSecrets })},
+	{key: "service_auth.mtls_names", env: []string{"SERVICE_MTLS_NAMES"}, usage: "accepted client certificate CNs: a,b,...",
+		set: listVar(func(c *Config) *[]string { return &c.ServiceAuth.MTLSNames })},
+	{key: "service_auth.max_clock_skew", env: []string{"SERVICE_AUTH_MAX_SKEW"}, def: "5m", usage: "max clock skew of signed service requests",
+		set: durationVar(func(c *Config) *time.Duration { return &c.ServiceAuth.MaxClockSkew })},
+
+	{key: "tls.cert_file", env: []string{"TLS_CERT_FILE"}, usage: "TLS certificate file",
+		set: stringVar(func(c *Config) *string { return &c.TLS.CertFile })},
+	{key: "tls.key_file", env: []string{"TLS_KEY_FILE"}, usage: "TLS private key file",
+		set: stringVar(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{key: "tls.client_ca_file", env: []string{"TLS_CLIENT_CA_FILE"}, usage: "CA for client certificates (mTLS)",
+		set: stringVar(func(c *Config) *string { return &c.TLS.ClientCAFile })},
+
+	{key: "relay.poll_interval", env: []string{"RELAY_POLL_INTERVAL"}, def: "2s", usage: "outbox relay poll interval",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Relay.PollInterval })},
+	{key: "relay.lease", env: []string{"RELAY_LEASE"}, def: "30s", usage: "outbox relay lease",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Relay.Lease })},
+	{key: "relay.max_attempts", env: []string{"RELAY_MAX_ATTEMPTS"}, def: "10", usage: "outbox relay max attempts",
+		set: intVar(func(c *Config) *int { return &c.Relay.MaxAttempts })},
+	{key: "relay.base_backoff", env: []string{"RELAY_BASE_BACKOFF"}, def: "1s", usage: "outbox relay base backoff",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Relay.BaseBackoff })},
+	{key: "relay.max_backoff", env: []string{"RELAY_MAX_BACKOFF"}, def: "5m", usage: "outbox relay max backoff",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Relay.MaxBackoff })},
+
+	{key: "order_sync.poll_interval", env: []string{"ORDER_SYNC_POLL_INTERVAL"}, def: "5s", usage: "orders sync worker poll interval",
+		set: durationVar(func(c *Config) *time.Duration { return &c.OrderSync.PollInterval })},
+	{key: "order_sync.lease", env: []string{"ORDER_SYNC_LEASE"}, def: "1m", usage: "orders sync lease",
+		set: durationVar(func(c *Config) *time.Duration { return &c.OrderSync.Lease })},
+	{key: "order_sync.max_attempts", env: []string{"ORDER_SYNC_MAX_ATTEMPTS"}, def: "10", usage: "orders sync max attempts",
+		set: intVar(func(c *Config) *int { return &c.OrderSync.MaxAttempts })},
+	{key: "order_sync.base_backoff", env: []string{"ORDER_SYNC_BASE_BACKOFF"}, def: "5s", usage: "orders sync base backoff",
+		set: durationVar(func(c *Config) *time.Duration { return &c.OrderSync.BaseBackoff })},
+	{key: "order_sync.max_backoff", env: []string{"ORDER_SYNC_MAX_BACKOFF"}, def: "30m", usage: "orders sync max backoff",
+		set: durationVar(func(c *Config) *time.Duration { return &c.OrderSync.MaxBackoff })},
+
+	{key: "webhooks.timeout", env: []string{"WEBHOOKS_TIMEOUT"}, def: "10s", usage: "webhook delivery timeout",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Webhooks.Timeout })},
+	{key: "webhooks.poll_interval", env: []string{"WEBHOOKS_POLL_INTERVAL"}, def: "5s", usage: "webhook worker poll interval",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Webhooks.PollInterval })},
+	{key: "webhooks.lease", env: []string{"WEBHOOKS_LEASE"}, def: "1m", usage: "webhook delivery lease",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Webhooks.Lease })},
+	{key: "webhooks.max_attempts", env: []string{"WEBHOOKS_MAX_ATTEMPTS"}, def: "8", usage: "webhook delivery max attempts",
+		set: intVar(func(c *Config) *int { return &c.Webhooks.MaxAttempts })},
+	{key: "webhooks.base_backoff", env: []string{"WEBHOOKS_BASE_BACKOFF"}, def: "5s", usage: "webhook delivery base backoff",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Webhooks.BaseBackoff })},
+	{key: "webhooks.max_backoff", env: []string{"WEBHOOKS_MAX_BACKOFF"}, def: "1h", usage: "webhook delivery max backoff",
+		set: durationVar(func(c *Config) *time.Duration { return &c.Webhooks.MaxBackoff })},
+}
+
+// auth.cache_ttl -> auth-cache-ttl
+func flagName(key string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(key)
+}
+
+func stringVar(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func intVar(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("'%s' is not an integer", v)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolVar(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a boolean", v)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func durationVar(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a duration (e.g. 30s, 5m)", v)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// Lista separada por comas: "a,b,c"
+func listVar(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = splitList(v)
+		return nil
+	}
+}
+
+// Pares nombre=valor separados por comas: "orders=abc,billing=def"
+func mapVar(field func(*Config) *map[string]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		m := map[string]string{}
+		for i, item := range splitList(v) {
+			name, value, ok := strings.Cut(item, "=")
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			// sin mostrar el valor: suelen ser secretos
+			if !ok || name == "" || value == "" {
+				return fmt.Errorf("entry %d is not a name=value pair", i+1)
+			}
+			m[name] = value
+		}
+		*field(c) = m
+		return nil
+	}
+}
+
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,134 @@
+// validate.go
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Devuelve todos los problemas encontrados (no solo el primero).
+// Las claves de invalid ya se reportaron al parsearlas y no se vuelven a validar.
+func (c *Config) validate(invalid map[string]bool) []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		add("port: '%s' is not a valid port", c.Port)
+	}
+
+	switch {
+	case c.Mongo.URI == "":
+		add("mongo.uri is required ($MONGO_URI)")
+	case !strings.HasPrefix(c.Mongo.URI, "mongodb://") && !strings.HasPrefix(c.Mongo.URI, "mongodb+srv://"):
+		add("mongo.uri must start with mongodb:// or mongodb+srv://")
+	}
+	if c.Mongo.Database == "" {
+		add("mongo.database is required ($MONGO_DB)")
+	}
+
+	problems = append(problems, validateURL("orders.url", c.Orders.URL)...)
+	switch c.Orders.Validation {
+	case OrdersValidationStrict, OrdersValidationLenient:
+	default:
+		add("orders.validation: '%s' must be strict or lenient", c.Orders.Validation)
+	}
+	problems = append(problems, validateURL("auth.url", c.Auth.URL)...)
+
+	switch c.Auth.Mode {
+	case "remote":
+	case "jwks":
+		problems = append(problems, validateURL("auth.jwks_url", c.Auth.JWKSURL)...)
+		if c.Auth.Audience == "" {
+			add("auth.audience is required when auth.mode is jwks ($AUTH_AUDIENCE)")
+		}
+	default:
+		add("auth.mode: '%s' must be remote or jwks", c.Auth.Mode)
+	}
+	if c.Auth.CacheSize < 0 && !invalid["auth.cache_size"] {
+		add("auth.cache_size must be >= 0")
+	}
+
+	if c.TLS.CertFile != "" && c.TLS.KeyFile == "" || c.TLS.CertFile == "" && c.TLS.KeyFile != "" {
+		add("tls.cert_file and tls.key_file must be set together")
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.Enabled() {
+		add("tls.client_ca_file requires tls.cert_file and tls.key_file")
+	}
+	if len(c.ServiceAuth.MTLSNames) > 0 && c.TLS.ClientCAFile == "" {
+		add("service_auth.mtls_names requires tls.client_ca_file")
+	}
+
+	positive := map[string]time.Duration{
+		"server.shutdown_timeout":     c.Server.ShutdownTimeout,
+		"mongo.connect_timeout":       c.Mongo.ConnectTimeout,
+		"mongo.retry_backoff":         c.Mongo.RetryBackoff,
+		"timeouts.read":               c.Timeouts.Read,
+		"timeouts.write":              c.Timeouts.Write,
+		"timeouts.bulk":               c.Timeouts.Bulk,
+		"idempotency.lease":           c.Idempotency.Lease,
+		"orders.timeout":              c.Orders.Timeout,
+		"orders.retry_backoff":        c.Orders.RetryBackoff,
+		"orders.breaker_cooldown":     c.Orders.BreakerCooldown,
+		"auth.jwks_refresh":           c.Auth.JWKSRefresh,
+		"auth.timeout":                c.Auth.Timeout,
+		"service_auth.max_clock_skew": c.ServiceAuth.MaxClockSkew,
+		"relay.poll_interval":         c.Relay.PollInterval,
+		"relay.lease":                 c.Relay.Lease,
+		"relay.base_backoff":          c.Relay.BaseBackoff,
+		"relay.max_backoff":           c.Relay.MaxBackoff,
+		"order_sync.poll_interval":    c.OrderSync.PollInterval,
+		"order_sync.lease":            c.OrderSync.Lease,
+		"order_sync.base_backoff":     c.OrderSync.BaseBackoff,
+		"order_sync.max_backoff":      c.OrderSync.MaxBackoff,
+		"webhooks.timeout":            c.Webhooks.Timeout,
+		"webhooks.poll_interval":      c.Webhooks.PollInterval,
+		"webhooks.lease":              c.Webhooks.Lease,
+		"webhooks.base_backoff":       c.Webhooks.BaseBackoff,
+		"webhooks.max_backoff":        c.Webhooks.MaxBackoff,
+	}
+	// en el orden de settings, para que el listado de errores sea estable
+	for _, s := range settings {
+		if d, ok := positive[s.key]; ok && d <= 0 && !invalid[s.key] {
+			add("%s must be a positive duration", s.key)
+		}
+	}
+	if c.Idempotency.Lease > 0 && c.Idempotency.Lease <= c.Timeouts.Write {
+		add("idempotency.lease must be longer than timeouts.write")
+	}
+	if c.Mongo.ConnectAttempts < 1 && !invalid["mongo.connect_attempts"] {
+		add("mongo.connect_attempts must be >= 1")
+	}
+	if c.Orders.MaxAttempts < 1 && !invalid["orders.max_attempts"] {
+		add("orders.max_attempts must be >= 1")
+	}
+	if c.Orders.BreakerThreshold < 1 && !invalid["orders.breaker_threshold"] {
+		add("orders.breaker_threshold must be >= 1")
+	}
+	if c.Relay.MaxAttempts < 1 && !invalid["relay.max_attempts"] {
+		add("relay.max_attempts must be >= 1")
+	}
+	if c.OrderSync.MaxAttempts < 1 && !invalid["order_sync.max_attempts"] {
+		add("order_sync.max_attempts must be >= 1")
+	}
+	if c.Webhooks.MaxAttempts < 1 && !invalid["webhooks.max_attempts"] {
+		add("webhooks.max_attempts must be >= 1")
+	}
+
+	return problems
+}
+
+func validateURL(key, value string) []string {
+	if value == "" {
+		return []string{key + " is required"}
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return []string{fmt.Sprintf("%s: '%s' is not a valid http(s) URL", key, value)}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// auth_admin_controller.go
+package controller
+
+import (
+	"net/http"
+	"order-status-service/internal/middleware"
+	"order-status-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthAdminController struct {
+	AuthService *service.AuthService
+}
+
+func NewAuthAdminController(router *gin.Engine, auth *service.AuthService) {
+	ctrl := &AuthAdminController{AuthService: auth}
+
+	group := router.Group("/admin/auth/cache")
+	group.Use(middleware.AuthMiddleware(ctrl.AuthService))
+	group.Use(middleware.AdminOnly())
+	{
+		group.GET("", ctrl.GetCacheStats)
+		group.DELETE("", ctrl.FlushCache)
+	}
+}
+
+// GET /admin/auth/cache
+func (ctrl *AuthAdminController) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.AuthService.CacheStats())
+}
+
+// DELETE /admin/auth/cache
+// Útil al revocar permisos o deshabilitar un usuario, para no esperar al TTL
+func (ctrl *AuthAdminController) FlushCache(c *gin.Context) {
+	flushed := ctrl.AuthService.FlushCache()
+	c.JSON(http.StatusOK, gin.H{"flushed": flushed})
+}
@@ -2,11 +2,10 @@
 package controller
 
 import (
-	"log"
 	"net/http"
+	"order-status-service/internal/dto"
 	"order-status-service/internal/middleware"
 	"order-status-service/internal/service"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,20 +25,23 @@ func NewCatalogAdminController(router *gin.Engine, svc *service.CatalogAdminServ
 		group.GET("", ctrl.GetAll)
 		group.POST("", ctrl.CreateStatus)
 		group.GET("/:id", ctrl.GetByID)
+		group.PATCH("/:id", ctrl.UpdateStatus)
+		group.DELETE("/:id", ctrl.DeleteStatus)
+		group.POST("/:id/archive", ctrl.ArchiveStatus)
+		group.PUT("/:id/transitions", ctrl.SetTransitions)
 	}
 }
 
 // POST /admin/status/catalog
 func (ctrl *CatalogAdminController) CreateStatus(c *gin.Context) {
-	var body struct {
-		Name string `json:"name"`
-	}
-	if err := c.ShouldBindJSON(&body); err != nil || body.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	var body dto.CreateCatalogStatusRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
-	if err := ctrl.Service.CreateStatus(body.Name); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	err := ctrl.Service.CreateStatus(c.Request.Context(), body)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "status added to catalog"})
@@ -47,67 +49,80 @@ func (ctrl *CatalogAdminController) CreateStatus(c *gin.Context) {
 
 // GET /admin/status/catalog
 func (ctrl *CatalogAdminController) GetAll(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+	statuses, err := ctrl.Service.GetAll(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-	token = strings.TrimSpace(token)
+	c.JSON(http.StatusOK, statuses)
+}
 
-	user, err := ctrl.AuthService.ValidateToken(token)
+// GET /admin/status/catalog/:id
+func (ctrl *CatalogAdminController) GetByID(c *gin.Context) {
+	id := c.Param("id")
 
+	result, err := ctrl.Service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		_ = c.Error(err)
 		return
 	}
 
-	if !ctrl.AuthService.IsAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+	c.JSON(http.StatusOK, result)
+}
+
+// PUT /admin/status/catalog/:id/transitions
+func (ctrl *CatalogAdminController) SetTransitions(c *gin.Context) {
+	var body dto.UpdateTransitionsRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	statuses, err := ctrl.Service.GetAll()
+	result, err := ctrl.Service.SetTransitions(c.Request.Context(), c.Param("id"), body)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, statuses)
+	c.JSON(http.StatusOK, result)
 }
 
-func (ctrl *CatalogAdminController) GetByID(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+// PATCH /admin/status/catalog/:id
+func (ctrl *CatalogAdminController) UpdateStatus(c *gin.Context) {
+	var body dto.UpdateCatalogStatusRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-	token = strings.TrimSpace(token)
-
-	log.Println("Token received in order-status-service:", c.GetHeader("Authorization"))
-
-	user, err := ctrl.AuthService.ValidateToken(token)
-
+	result, err := ctrl.Service.UpdateStatus(c.Request.Context(), c.Param("id"), body)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		_ = c.Error(err)
 		return
 	}
 
-	if !ctrl.AuthService.IsAdmin(user) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /admin/status/catalog/:id/archive
+func (ctrl *CatalogAdminController) ArchiveStatus(c *gin.Context) {
+	result, err := ctrl.Service.ArchiveStatus(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	id := c.Param("id")
+	c.JSON(http.StatusOK, result)
+}
 
-	result, err := ctrl.Service.GetByID(id)
+// DELETE /admin/status/catalog/:id
+func (ctrl *CatalogAdminController) DeleteStatus(c *gin.Context) {
+	err := ctrl.Service.DeleteStatus(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "status not found"})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, gin.H{"message": "status removed from catalog"})
 }
@@ -0,0 +1,43 @@
+// etag.go
+package controller
+
+import (
+	"order-status-service/internal/service"
+	"strconv"
+	"strings"
+)
+
+// ETag de un estado de orden, derivado de su versión
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Interpreta la cabecera If-Match (RFC 9110) y devuelve las versiones aceptadas.
+// Devuelve nil si no se envió o es "*" (basta con que la orden exista).
+// La comparación es fuerte: los ETags débiles (W/"3") y los que no son una versión nunca
+// coinciden, así que una lista sin ETags fuertes válidos hace fallar la precondición (412).
+func parseIfMatch(header string) ([]int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		weak := strings.HasPrefix(tag, "W/")
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || strings.Count(tag, `"`) != 2 {
+			return nil, service.NewError(service.ErrInvalidInput, "request.invalid_if_match")
+		}
+		if weak {
+			continue
+		}
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || version < 0 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
@@ -5,18 +5,30 @@ import (
 	"net/http"
 	"order-status-service/internal/dto"
 	"order-status-service/internal/middleware"
+	"order-status-service/internal/policy"
 	"order-status-service/internal/service"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Intervalo de los heartbeats del stream SSE, para que proxies y clientes no cierren la conexión
+const streamHeartbeatInterval = 15 * time.Second
+
+var (
+	errAdminRequired = service.NewError(policy.ErrForbidden, "auth.admin_required")
+	// A quien no puede ver la orden se le responde igual que si no existiera
+	errOrderNotFound = service.NewError(service.ErrNotFound, "order_status.not_found")
+)
+
 type OrderStatusController struct {
 	Service     *service.OrderStatusService
 	AuthService *service.AuthService
 }
 
-func NewOrderStatusController(router *gin.Engine, svc *service.OrderStatusService, authSvc *service.AuthService) {
+func NewOrderStatusController(router *gin.Engine, svc *service.OrderStatusService, authSvc *service.AuthService, idemSvc *service.IdempotencyService, serviceAuth *service.ServiceAuthService) {
 	ctrl := &OrderStatusController{Service: svc, AuthService: authSvc}
+	idempotent := middleware.Idempotency(idemSvc)
 
 	// Grupo con autenticación
 	auth := router.Group("/status")
@@ -24,183 +36,341 @@ func NewOrderStatusController(router *gin.Engine, svc *service.OrderStatusServic
 
 	auth.GET("", ctrl.GetStatusesByUser)
 	auth.POST("", ctrl.CreateStatus)
-	auth.PUT("/:id", ctrl.UpdateStatus)
+	auth.PUT("/:id", idempotent, ctrl.UpdateStatus)
+	auth.PATCH("/:id/shipping", idempotent, ctrl.UpdateShipping)
 	auth.GET("/all", ctrl.GetAllOrderStatuses)
 	auth.GET("/filter", ctrl.FilterByStatus)
+	auth.GET("/:id/stream", ctrl.StreamStatus)
+	auth.GET("/:id/history", ctrl.GetHistory)
+
+	// Mismas rutas, identificando la orden por su order_id
+	auth.GET("/order/:orderId", ctrl.GetByOrderID)
+	auth.PUT("/order/:orderId", idempotent, ctrl.UpdateStatusByOrderID)
 
-	// Rutas públicas (sin autenticación)
+	// Historial de todas las órdenes (solo admin)
+	admin := router.Group("/admin/status/history")
+	admin.Use(middleware.AuthMiddleware(authSvc))
+	admin.Use(middleware.AdminOnly())
+	admin.GET("", ctrl.GetHistoryFeed)
+
+	// Rutas internas: solo otros microservicios (API key, firma HMAC o mTLS)
 	// router.GET("/status/all", ctrl.GetAllStatuses)
-	router.POST("/status/init", ctrl.InitStatus)
+	router.POST("/status/init", middleware.ServiceAuth(serviceAuth), idempotent, ctrl.InitStatus)
 }
 
 func (ctrl *OrderStatusController) GetAllStatuses(c *gin.Context) {
-	statuses, err := ctrl.Service.GetAllStatuses()
+	statuses, err := ctrl.Service.GetAllStatuses(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, statuses)
 }
 
 func (ctrl *OrderStatusController) GetAllOrderStatuses(c *gin.Context) {
-	permissions := c.GetStringSlice("userPermissions")
-	isAdmin := false
-	for _, p := range permissions {
-		if p == "admin" {
-			isAdmin = true
-			break
-		}
-	}
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: admin access required"})
+	if !isAdmin(c) {
+		_ = c.Error(errAdminRequired)
 		return
 	}
 
-	statuses, err := ctrl.Service.GetAllOrderStatuses()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var query dto.OrderStatusListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
-	c.JSON(http.StatusOK, statuses)
+	ctrl.list(c, query)
 }
 
 func (ctrl *OrderStatusController) GetStatusesByUser(c *gin.Context) {
-	userID := c.GetString("userID")
-	statuses, err := ctrl.Service.GetStatusesByUser(userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var query dto.OrderStatusListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
-	c.JSON(http.StatusOK, statuses)
+	// un usuario solo puede listar sus propias órdenes (un vendedor, también las que tiene asignadas)
+	actor := actorFrom(c)
+	query.UserID, query.SellerID = "", ""
+	if actor.Role == policy.RoleSeller {
+		query.Party = actor.ID
+	} else {
+		query.UserID = actor.ID
+	}
+	ctrl.list(c, query)
 }
 
 func (ctrl *OrderStatusController) CreateStatus(c *gin.Context) {
-	permissions := c.GetStringSlice("userPermissions")
-	isAdmin := false
-	for _, p := range permissions {
-		if p == "admin" {
-			isAdmin = true
-			break
-		}
-	}
-
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: admin access required"})
+	if !isAdmin(c) {
+		_ = c.Error(errAdminRequired)
 		return
 	}
 
 	var req dto.CreateOrderStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	status, err := ctrl.Service.CreateStatus(req)
+	status, err := ctrl.Service.CreateStatus(c.Request.Context(), req, actorFrom(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusCreated, status)
+	c.Header("ETag", versionETag(status.Version))
+	c.JSON(http.StatusCreated, ctrl.Service.LocalizeOne(c.Request.Context(), status, c.GetString("locale")))
 }
 
+// PUT /status/:id
 func (ctrl *OrderStatusController) UpdateStatus(c *gin.Context) {
-	permissions := c.GetStringSlice("userPermissions")
-
-	role := "client"
-	for _, p := range permissions {
-		if p == "admin" {
-			role = "admin"
-			break
-		}
+	current, err := ctrl.Service.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
+	ctrl.changeStatus(c, current)
+}
 
-	userID := c.GetString("userID")
-	id := c.Param("id")
+// PUT /status/order/:orderId
+func (ctrl *OrderStatusController) UpdateStatusByOrderID(c *gin.Context) {
+	current, err := ctrl.Service.GetByOrderID(c.Request.Context(), c.Param("orderId"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	ctrl.changeStatus(c, current)
+}
 
+// Aplica el cambio de estado pedido sobre una orden ya resuelta
+func (ctrl *OrderStatusController) changeStatus(c *gin.Context, current dto.OrderStatusDTO) {
 	var req dto.UpdateOrderStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	// Si no es admin, validar estados prohibidos
-	if role == "client" {
-		// Solo se permite cambiar a CANCELADO
-		ok, err := ctrl.Service.IsCancelStatus(req.StatusID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: admin access required"})
-			return
-		}
+	// La pertenencia de la orden y las reglas por rol (p. ej. el cliente solo puede cancelar)
+	// se validan en el servicio contra la política y el grafo de transiciones del catálogo
+	expectedVersions, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
 
-	result, err := ctrl.Service.ChangeStatus(id, req.StatusID, userID, role, req.Reason)
+	result, err := ctrl.Service.ChangeStatus(c.Request.Context(), current.ID, req.StatusID, actorFrom(c), req.Reason, expectedVersions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.Header("ETag", versionETag(result.Version))
+	c.JSON(http.StatusOK, ctrl.Service.LocalizeOne(c.Request.Context(), result, c.GetString("locale")))
 }
 
-func (ctrl *OrderStatusController) FilterByStatus(c *gin.Context) {
+// PATCH /status/:id/shipping
+// Corrige la dirección de envío antes del despacho (solo el dueño o un admin)
+func (ctrl *OrderStatusController) UpdateShipping(c *gin.Context) {
+	var req dto.UpdateShippingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
 
-	// SOLO ADMIN
-	permissions := c.GetStringSlice("userPermissions")
-	isAdmin := false
-	for _, p := range permissions {
-		if p == "admin" {
-			isAdmin = true
-			break
-		}
+	expectedVersions, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	result, err := ctrl.Service.UpdateShipping(c.Request.Context(), c.Param("id"), req, actorFrom(c), expectedVersions)
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: admin access required"})
+
+	c.Header("ETag", versionETag(result.Version))
+	c.JSON(http.StatusOK, ctrl.Service.LocalizeOne(c.Request.Context(), result, c.GetString("locale")))
+}
+
+// GET /status/order/:orderId
+// Estado de una orden por su order_id (solo el dueño o un admin)
+func (ctrl *OrderStatusController) GetByOrderID(c *gin.Context) {
+	current, err := ctrl.Service.GetByOrderID(c.Request.Context(), c.Param("orderId"))
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
+	if !canView(c, current) {
+		_ = c.Error(errOrderNotFound)
+		return
+	}
+	c.Header("ETag", versionETag(current.Version))
+	c.JSON(http.StatusOK, ctrl.Service.LocalizeOne(c.Request.Context(), current, c.GetString("locale")))
+}
 
-	statusID := c.Query("status_id")
-	if statusID != "" {
-		results, err := ctrl.Service.GetByStatusID(statusID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, results)
+func (ctrl *OrderStatusController) FilterByStatus(c *gin.Context) {
+
+	// SOLO ADMIN
+	if !isAdmin(c) {
+		_ = c.Error(errAdminRequired)
 		return
 	}
 
-	statusName := c.Query("status")
-	if statusName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing status or status_id param"})
+	var query dto.OrderStatusListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	if len(query.Status) == 0 && len(query.StatusID) == 0 {
+		_ = c.Error(service.NewError(service.ErrInvalidQuery, "query.missing_status_filter"))
 		return
 	}
+	ctrl.list(c, query)
+}
 
-	results, err := ctrl.Service.GetByStatus(statusName)
+// Responde una página de estados de órdenes, con las etiquetas en el idioma pedido
+func (ctrl *OrderStatusController) list(c *gin.Context, query dto.OrderStatusListQuery) {
+	page, err := ctrl.Service.ListOrderStatuses(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, results)
+	page.Items = ctrl.Service.Localize(c.Request.Context(), page.Items, c.GetString("locale"))
+	c.JSON(http.StatusOK, page)
 }
 
 func (ctrl *OrderStatusController) InitStatus(c *gin.Context) {
 	var req dto.CreateOrderStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	// Estado inicial: el marcado como is_initial en el catálogo
+	req.Status = ""
+	req.StatusID = ""
+
+	// El servicio llamador queda registrado como actor del estado inicial
+	actor := policy.Actor{ID: c.GetString("serviceName"), Role: policy.RoleSystem}
+	status, err := ctrl.Service.CreateStatus(c.Request.Context(), req, actor)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
+	c.Header("ETag", versionETag(status.Version))
+	c.JSON(http.StatusCreated, ctrl.Service.LocalizeOne(c.Request.Context(), status, c.GetString("locale")))
+}
 
-	req.Status = "Pendiente" // Estado inicial
+// GET /status/:id/stream
+// Stream SSE con cada cambio de estado de la orden (solo el dueño o un admin)
+func (ctrl *OrderStatusController) StreamStatus(c *gin.Context) {
+	id := c.Param("id")
+	locale := c.GetString("locale")
 
-	status, err := ctrl.Service.CreateStatus(req)
+	current, err := ctrl.Service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusCreated, status)
+
+	if !canView(c, current) {
+		_ = c.Error(errOrderNotFound)
+		return
+	}
+
+	// Los eventos se publican con el id del documento en hex minúsculas, no con el de la ruta
+	events, unsubscribe := ctrl.Service.Subscribe(current.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Estado actual al conectarse, para no depender del polling previo
+	c.SSEvent("status", ctrl.Service.LocalizeOne(c.Request.Context(), current, locale))
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"at": time.Now()})
+			c.Writer.Flush()
+		case event, ok := <-events:
+			if !ok {
+				// el broker nos desconectó (cliente lento o apagado del servicio)
+				return
+			}
+			name := "status_changed"
+			if event.Shipping != nil {
+				name = "shipping_updated"
+			}
+			c.SSEvent(name, ctrl.Service.LocalizeEvent(c.Request.Context(), event, locale))
+			c.Writer.Flush()
+		}
+	}
+}
+
+// GET /status/:id/history
+// Historial de cambios de estado de una orden (solo el dueño o un admin)
+func (ctrl *OrderStatusController) GetHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	current, err := ctrl.Service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if !canView(c, current) {
+		_ = c.Error(errOrderNotFound)
+		return
+	}
+
+	var query dto.StatusHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	page, err := ctrl.Service.GetHistory(c.Request.Context(), id, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GET /admin/status/history
+func (ctrl *OrderStatusController) GetHistoryFeed(c *gin.Context) {
+	var query dto.StatusHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	page, err := ctrl.Service.GetHistoryFeed(c.Request.Context(), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// Actor autenticado de la request, con el rol resuelto a partir de sus permisos
+func actorFrom(c *gin.Context) policy.Actor {
+	return policy.NewActor(c.GetString("userID"), c.GetStringSlice("userPermissions"))
+}
+
+// Indica si el usuario autenticado es admin
+func isAdmin(c *gin.Context) bool {
+	return actorFrom(c).Role == policy.RoleAdmin
+}
+
+// Solo el dueño de la orden, un vendedor asignado o un admin pueden verla
+func canView(c *gin.Context, status dto.OrderStatusDTO) bool {
+	return policy.CanView(actorFrom(c), policy.Order{OwnerID: status.UserID, SellerIDs: status.SellerIDs})
 }
@@ -0,0 +1,48 @@
+// order_sync_admin_controller.go
+package controller
+
+import (
+	"net/http"
+	"order-status-service/internal/middleware"
+	"order-status-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderSyncAdminController struct {
+	Service     *service.OrderSyncService
+	AuthService *service.AuthService
+}
+
+func NewOrderSyncAdminController(router *gin.Engine, svc *service.OrderSyncService, auth *service.AuthService) {
+	ctrl := &OrderSyncAdminController{Service: svc, AuthService: auth}
+
+	group := router.Group("/admin/orders-sync")
+	group.Use(middleware.AuthMiddleware(ctrl.AuthService))
+	group.Use(middleware.AdminOnly())
+	{
+		group.GET("", ctrl.GetAll)
+		group.POST("/:id/retry", ctrl.Retry)
+	}
+}
+
+// GET /admin/orders-sync?status=failed
+// Sin status devuelve los avisos fallidos
+func (ctrl *OrderSyncAdminController) GetAll(c *gin.Context) {
+	syncs, err := ctrl.Service.GetSyncs(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, syncs)
+}
+
+// POST /admin/orders-sync/:id/retry
+func (ctrl *OrderSyncAdminController) Retry(c *gin.Context) {
+	err := ctrl.Service.Retry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "sync scheduled"})
+}
@@ -0,0 +1,98 @@
+// webhook_admin_controller.go
+package controller
+
+import (
+	"net/http"
+	"order-status-service/internal/dto"
+	"order-status-service/internal/middleware"
+	"order-status-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookAdminController struct {
+	Service     *service.WebhookService
+	AuthService *service.AuthService
+}
+
+func NewWebhookAdminController(router *gin.Engine, svc *service.WebhookService, auth *service.AuthService) {
+	ctrl := &WebhookAdminController{Service: svc, AuthService: auth}
+
+	group := router.Group("/admin/webhooks")
+	group.Use(middleware.AuthMiddleware(ctrl.AuthService))
+	group.Use(middleware.AdminOnly())
+	{
+		group.GET("", ctrl.GetAll)
+		group.POST("", ctrl.Create)
+		group.GET("/:id", ctrl.GetByID)
+		group.DELETE("/:id", ctrl.Delete)
+		group.GET("/:id/deliveries", ctrl.GetDeliveries)
+		group.POST("/deliveries/:deliveryId/redeliver", ctrl.Redeliver)
+	}
+}
+
+// POST /admin/webhooks
+func (ctrl *WebhookAdminController) Create(c *gin.Context) {
+	var body dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	result, err := ctrl.Service.CreateSubscription(c.Request.Context(), body)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// GET /admin/webhooks
+func (ctrl *WebhookAdminController) GetAll(c *gin.Context) {
+	subs, err := ctrl.Service.GetSubscriptions(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// GET /admin/webhooks/:id
+func (ctrl *WebhookAdminController) GetByID(c *gin.Context) {
+	sub, err := ctrl.Service.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// DELETE /admin/webhooks/:id
+func (ctrl *WebhookAdminController) Delete(c *gin.Context) {
+	err := ctrl.Service.DeleteSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "webhook removed"})
+}
+
+// GET /admin/webhooks/:id/deliveries?status=failed
+func (ctrl *WebhookAdminController) GetDeliveries(c *gin.Context) {
+	deliveries, err := ctrl.Service.GetDeliveries(c.Request.Context(), c.Param("id"), c.Query("status"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// POST /admin/webhooks/deliveries/:deliveryId/redeliver
+func (ctrl *WebhookAdminController) Redeliver(c *gin.Context) {
+	err := ctrl.Service.Redeliver(c.Request.Context(), c.Param("deliveryId"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "delivery scheduled"})
+}
@@ -0,0 +1,49 @@
+// mongo.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"order-status-service/internal/config"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Connect abre el cliente de MongoDB y espera a que responda un ping, reintentando con
+// backoff exponencial (útil cuando Mongo arranca junto con el servicio en docker-compose)
+func Connect(ctx context.Context, cfg config.MongoConfig) (*mongo.Client, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := cfg.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+		err = client.Ping(pingCtx, readpref.Primary())
+		cancel()
+		if err == nil {
+			return client, nil
+		}
+		if attempt >= cfg.ConnectAttempts {
+			break
+		}
+
+		log.Printf("⚠️ MongoDB not ready (attempt %d/%d): %v, retrying in %s", attempt, cfg.ConnectAttempts, err, backoff)
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+			continue
+		}
+		break
+	}
+
+	_ = client.Disconnect(context.Background())
+	return nil, fmt.Errorf("mongodb not reachable after %d attempts: %w", cfg.ConnectAttempts, err)
+}
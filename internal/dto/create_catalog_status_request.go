@@ -1,6 +1,33 @@
 // create_catalog_status_request.go
 package dto
 
+type TransitionDTO struct {
+	ToID  string   `json:"to_id" binding:"required"`
+	Roles []string `json:"roles" binding:"required,min=1"`
+}
+
 type CreateCatalogStatusRequest struct {
-	Status string `json:"status" binding:"required"`
+	Name string `json:"name" binding:"required"`
+	// Código estable; si no se envía se deriva del nombre
+	Code        string            `json:"code,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	IsInitial   bool              `json:"is_initial"`
+	IsTerminal  bool              `json:"is_terminal"`
+	Category    string            `json:"category" binding:"required"`
+	Transitions []TransitionDTO   `json:"transitions,omitempty" binding:"omitempty,dive"`
+}
+
+// Reemplaza las transiciones salientes de un estado del catálogo
+type UpdateTransitionsRequest struct {
+	Transitions []TransitionDTO `json:"transitions" binding:"dive"`
+}
+
+// PATCH: solo se modifican los campos enviados
+type UpdateCatalogStatusRequest struct {
+	Name *string `json:"name,omitempty"`
+	// Si se envía, reemplaza todas las etiquetas
+	Labels     map[string]string `json:"labels,omitempty"`
+	IsInitial  *bool             `json:"is_initial,omitempty"`
+	IsTerminal *bool             `json:"is_terminal,omitempty"`
+	Category   *string           `json:"category,omitempty"`
 }
@@ -19,6 +19,19 @@ type CreateOrderStatusRequest struct {
 	Status   string      `json:"status"`              // opcional por compat
 	StatusID string      `json:"status_id,omitempty"` // prefiero este
 	Shipping ShippingDTO `json:"shipping" binding:"required"`
+	// Vendedores asignados a la orden
+	SellerIDs []string `json:"seller_ids,omitempty"`
+}
+
+// Corrección de la dirección de envío: solo cambian los campos enviados
+type UpdateShippingRequest struct {
+	AddressLine1 *string `json:"address_line1,omitempty"`
+	AddressLine2 *string `json:"address_line2,omitempty"`
+	City         *string `json:"city,omitempty"`
+	Province     *string `json:"province,omitempty"`
+	Country      *string `json:"country,omitempty"`
+	Zipcode      *string `json:"zipcode,omitempty"`
+	Comments     *string `json:"comments,omitempty"`
 }
 
 // Update request: ahora pedimos status_id
@@ -29,13 +42,53 @@ type UpdateOrderStatusRequest struct {
 
 // DTO de respuesta
 type OrderStatusDTO struct {
-	ID        string      `json:"id" bson:"_id,omitempty"`
-	OrderID   string      `json:"order_id" bson:"order_id"`
-	UserID    string      `json:"user_id" bson:"user_id"`
-	StatusID  string      `json:"status_id" bson:"status_id"`
-	Status    string      `json:"status" bson:"status"`
-	Shipping  ShippingDTO `json:"shipping"`
-	History   []any       `json:"history,omitempty"`
-	CreatedAt time.Time   `json:"created_at"`
-	UpdatedAt time.Time   `json:"updated_at"`
+	ID        string   `json:"id" bson:"_id,omitempty"`
+	OrderID   string   `json:"order_id" bson:"order_id"`
+	UserID    string   `json:"user_id" bson:"user_id"`
+	SellerIDs []string `json:"seller_ids,omitempty" bson:"seller_ids"`
+	StatusID  string   `json:"status_id" bson:"status_id"`
+	// Código estable del estado y su etiqueta en el idioma pedido
+	StatusCode string           `json:"status_code,omitempty" bson:"-"`
+	Status     string           `json:"status" bson:"status"`
+	Shipping   ShippingDTO      `json:"shipping"`
+	History    []StatusEntryDTO `json:"history,omitempty"`
+	// Correcciones de la dirección de envío
+	ShippingHistory []ShippingRevisionDTO `json:"shipping_history,omitempty"`
+	Version         int64                 `json:"version"`
+	CreatedAt       time.Time             `json:"created_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+}
+
+// Entrada del historial de cambios de estado
+type StatusEntryDTO struct {
+	ID     string    `json:"id"`
+	Status string    `json:"status"`
+	UserID string    `json:"user_id,omitempty"`
+	Role   string    `json:"role,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Corrección de la dirección de envío
+type ShippingRevisionDTO struct {
+	ID     string      `json:"id"`
+	Old    ShippingDTO `json:"old"`
+	New    ShippingDTO `json:"new"`
+	UserID string      `json:"user_id,omitempty"`
+	Role   string      `json:"role,omitempty"`
+	At     time.Time   `json:"at"`
+}
+
+// Entrada del historial junto con la orden a la que pertenece
+type StatusHistoryItemDTO struct {
+	OrderStatusID string         `json:"order_status_id"`
+	OrderID       string         `json:"order_id"`
+	OwnerID       string         `json:"owner_id"`
+	Entry         StatusEntryDTO `json:"entry"`
+}
+
+// Página del historial; next_cursor se omite en la última página
+type StatusHistoryPage struct {
+	Items      []StatusHistoryItemDTO `json:"items"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,39 @@
+// order_status_query.go
+package dto
+
+// Parámetros de los listados paginados (GET /status, /status/all, /status/filter)
+type OrderStatusListQuery struct {
+	Cursor   string   `form:"cursor"`
+	Limit    int      `form:"limit"`
+	Sort     string   `form:"sort"`  // created_at (default) | updated_at
+	Order    string   `form:"order"` // desc (default) | asc
+	Status   []string `form:"status"`
+	StatusID []string `form:"status_id"`
+	From     string   `form:"from"` // RFC3339, inclusive
+	To       string   `form:"to"`   // RFC3339, exclusive
+	Country  string   `form:"country"`
+	City     string   `form:"city"`
+	UserID   string   `form:"user_id"`   // solo admins
+	SellerID string   `form:"seller_id"` // solo admins
+	// Lo completa el controller: órdenes de las que el usuario es dueño o vendedor asignado
+	Party string `form:"-"`
+}
+
+// Página de resultados; next_cursor se omite en la última página
+type OrderStatusPage struct {
+	Items      []OrderStatusDTO `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// Parámetros de las consultas de historial (GET /status/:id/history y /admin/status/history)
+type StatusHistoryQuery struct {
+	Cursor  string `form:"cursor"`
+	Limit   int    `form:"limit"`
+	Order   string `form:"order"` // asc | desc
+	Role    string `form:"role"`
+	UserID  string `form:"user_id"` // actor que hizo el cambio
+	Status  string `form:"status"`
+	From    string `form:"from"`     // RFC3339, inclusive
+	To      string `form:"to"`       // RFC3339, exclusive
+	OrderID string `form:"order_id"` // solo en el feed de admin
+}
@@ -0,0 +1,22 @@
+// webhook_dto.go
+package dto
+
+// Alta de una suscripción a webhooks
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Si no se envía, se genera una y se devuelve una única vez
+	Secret    string   `json:"secret,omitempty"`
+	StatusIDs []string `json:"status_ids,omitempty"`
+	// Si no se envía, solo order.status_changed
+	Events []string `json:"events,omitempty"`
+}
+
+// Respuesta del alta: es el único momento en que se expone el secreto
+type CreateWebhookResponse struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	StatusIDs []string `json:"status_ids"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+}
@@ -0,0 +1,26 @@
+// fanout.go
+package events
+
+import (
+	"context"
+	"errors"
+	"order-status-service/internal/model"
+)
+
+// Fanout entrega cada evento a varios publicadores (log, webhooks, servicio de órdenes...).
+// Si alguno falla, el relay reintenta el evento completo, por lo que todos deben ser idempotentes.
+type Fanout []EventPublisher
+
+func NewFanout(publishers ...EventPublisher) Fanout {
+	return Fanout(publishers)
+}
+
+func (f Fanout) Publish(ctx context.Context, event model.OutboxEvent) error {
+	var errs []error
+	for _, p := range f {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
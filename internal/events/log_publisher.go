@@ -0,0 +1,25 @@
+// log_publisher.go
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"order-status-service/internal/model"
+)
+
+// LogPublisher escribe cada evento en el log; útil mientras no haya un broker configurado
+type LogPublisher struct{}
+
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event model.OutboxEvent) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	log.Printf("📣 %s [%s] %s", event.Type, event.ID.Hex(), payload)
+	return nil
+}
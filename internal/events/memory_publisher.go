@@ -0,0 +1,44 @@
+// memory_publisher.go
+package events
+
+import (
+	"context"
+	"order-status-service/internal/model"
+	"sync"
+)
+
+// MemoryPublisher guarda los eventos en memoria; lo usan los tests del relay
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []model.OutboxEvent
+	// Si no es nil, Publish devuelve este error sin guardar el evento
+	Err error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event model.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return p.Err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events devuelve una copia de los eventos publicados
+func (p *MemoryPublisher) Events() []model.OutboxEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]model.OutboxEvent(nil), p.events...)
+}
+
+// Reset descarta los eventos publicados
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = nil
+}
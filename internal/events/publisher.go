@@ -0,0 +1,14 @@
+// publisher.go
+package events
+
+import (
+	"context"
+	"order-status-service/internal/model"
+)
+
+// EventPublisher entrega los eventos del outbox al resto del sistema
+// (broker de mensajes, log, etc.). Publish debe devolver error si el evento
+// no pudo entregarse, para que el relay lo reintente.
+type EventPublisher interface {
+	Publish(ctx context.Context, event model.OutboxEvent) error
+}
@@ -0,0 +1,108 @@
+// relay.go
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"order-status-service/internal/config"
+	"order-status-service/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OutboxStore es la parte del outbox que usa el relay (la implementa repository.OutboxRepository).
+// ClaimNext devuelve mongo.ErrNoDocuments cuando no hay eventos vencidos.
+type OutboxStore interface {
+	ClaimNext(ctx context.Context, lease time.Duration) (model.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id primitive.ObjectID) error
+	MarkRetry(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, lastErr string) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, lastErr string) error
+}
+
+// Relay lee los eventos pendientes del outbox y los publica, reintentando con backoff exponencial
+type Relay struct {
+	repo      OutboxStore
+	publisher EventPublisher
+
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+func NewRelay(repo OutboxStore, publisher EventPublisher, cfg config.WorkerConfig) *Relay {
+	return &Relay{
+		repo:         repo,
+		publisher:    publisher,
+		PollInterval: cfg.PollInterval,
+		Lease:        cfg.Lease,
+		MaxAttempts:  cfg.MaxAttempts,
+		BaseBackoff:  cfg.BaseBackoff,
+		MaxBackoff:   cfg.MaxBackoff,
+	}
+}
+
+// Run procesa el outbox hasta que se cancele el contexto
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publica todos los eventos vencidos disponibles en este momento
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		event, err := r.repo.ClaimNext(ctx, r.Lease)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return
+		}
+		if err != nil {
+			log.Printf("⚠️ Outbox: error al leer eventos pendientes: %v", err)
+			return
+		}
+
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			attempts := event.Attempts + 1
+			if attempts >= r.MaxAttempts {
+				log.Printf("❌ Outbox: evento %s descartado tras %d intentos: %v", event.ID.Hex(), attempts, err)
+				if err := r.repo.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+					log.Printf("⚠️ Outbox: error al marcar evento %s como fallido: %v", event.ID.Hex(), err)
+				}
+				continue
+			}
+			next := time.Now().Add(r.backoff(attempts))
+			if err := r.repo.MarkRetry(ctx, event.ID, next, err.Error()); err != nil {
+				log.Printf("⚠️ Outbox: error al reprogramar evento %s: %v", event.ID.Hex(), err)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkPublished(ctx, event.ID); err != nil {
+			log.Printf("⚠️ Outbox: error al marcar evento %s como publicado: %v", event.ID.Hex(), err)
+		}
+	}
+}
+
+// Espera antes del próximo intento: BaseBackoff * 2^(intentos-1), acotado por MaxBackoff
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,187 @@
+// relay_test.go
+package events
+
+import (
+	"context"
+	"errors"
+	"order-status-service/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Outbox en memoria con la misma semántica de ClaimNext que el repositorio de Mongo
+type memoryOutbox struct {
+	mu     sync.Mutex
+	events []model.OutboxEvent
+}
+
+func (o *memoryOutbox) add(event model.OutboxEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	event.Status = model.OutboxPending
+	o.events = append(o.events, event)
+}
+
+func (o *memoryOutbox) get(id primitive.ObjectID) model.OutboxEvent {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, e := range o.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return model.OutboxEvent{}
+}
+
+func (o *memoryOutbox) update(id primitive.ObjectID, fn func(e *model.OutboxEvent)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.events {
+		if o.events[i].ID == id {
+			fn(&o.events[i])
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (o *memoryOutbox) ClaimNext(ctx context.Context, lease time.Duration) (model.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := time.Now()
+	for i, e := range o.events {
+		if e.Status == model.OutboxPending && !e.NextAttemptAt.After(now) {
+			o.events[i].NextAttemptAt = now.Add(lease)
+			return o.events[i], nil
+		}
+	}
+	return model.OutboxEvent{}, mongo.ErrNoDocuments
+}
+
+func (o *memoryOutbox) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	return o.update(id, func(e *model.OutboxEvent) {
+		now := time.Now()
+		e.Status, e.PublishedAt, e.LastError = model.OutboxPublished, &now, ""
+		e.Attempts++
+	})
+}
+
+func (o *memoryOutbox) MarkRetry(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, lastErr string) error {
+	return o.update(id, func(e *model.OutboxEvent) {
+		e.NextAttemptAt, e.LastError = nextAttemptAt, lastErr
+		e.Attempts++
+	})
+}
+
+func (o *memoryOutbox) MarkFailed(ctx context.Context, id primitive.ObjectID, lastErr string) error {
+	return o.update(id, func(e *model.OutboxEvent) {
+		e.Status, e.LastError = model.OutboxFailed, lastErr
+		e.Attempts++
+	})
+}
+
+func newTestRelay(outbox *memoryOutbox, publisher EventPublisher, maxAttempts int) *Relay {
+	return &Relay{
+		repo:        outbox,
+		publisher:   publisher,
+		Lease:       time.Minute,
+		MaxAttempts: maxAttempts,
+		BaseBackoff: time.Second,
+		MaxBackoff:  4 * time.Second,
+	}
+}
+
+func newEvent() model.OutboxEvent {
+	id := primitive.NewObjectID()
+	return model.OutboxEvent{
+		ID:          id,
+		Type:        model.EventStatusChanged,
+		AggregateID: id.Hex(),
+		Payload:     model.OrderStatusEventPayload{OrderID: "order-" + id.Hex()},
+	}
+}
+
+func TestRelayPublishesAndMarksPublished(t *testing.T) {
+	outbox := &memoryOutbox{}
+	publisher := NewMemoryPublisher()
+	first, second := newEvent(), newEvent()
+	outbox.add(first)
+	outbox.add(second)
+
+	newTestRelay(outbox, publisher, 3).drain(context.Background())
+
+	published := publisher.Events()
+	if len(published) != 2 || published[0].ID != first.ID || published[1].ID != second.ID {
+		t.Fatalf("published = %+v, want both events in order", published)
+	}
+	for _, e := range []model.OutboxEvent{first, second} {
+		got := outbox.get(e.ID)
+		if got.Status != model.OutboxPublished || got.PublishedAt == nil || got.Attempts != 1 {
+			t.Errorf("event %s = status %s, published_at %v, attempts %d; want published once", e.ID.Hex(), got.Status, got.PublishedAt, got.Attempts)
+		}
+	}
+}
+
+func TestRelaySchedulesRetryOnPublishError(t *testing.T) {
+	outbox := &memoryOutbox{}
+	publisher := NewMemoryPublisher()
+	publisher.Err = errors.New("broker down")
+	event := newEvent()
+	outbox.add(event)
+
+	before := time.Now()
+	newTestRelay(outbox, publisher, 3).drain(context.Background())
+
+	got := outbox.get(event.ID)
+	if got.Status != model.OutboxPending || got.Attempts != 1 || got.LastError != "broker down" {
+		t.Fatalf("event = status %s, attempts %d, last_error %q; want pending retry", got.Status, got.Attempts, got.LastError)
+	}
+	if wait := got.NextAttemptAt.Sub(before); wait < time.Second || wait > 2*time.Second {
+		t.Errorf("next attempt in %s, want the base backoff (1s)", wait)
+	}
+	if len(publisher.Events()) != 0 {
+		t.Errorf("a failed publish must not record the event")
+	}
+
+	// Cuando el broker vuelve, el reintento vencido se publica
+	publisher.Err = nil
+	outbox.update(event.ID, func(e *model.OutboxEvent) { e.NextAttemptAt = time.Now() })
+	newTestRelay(outbox, publisher, 3).drain(context.Background())
+
+	got = outbox.get(event.ID)
+	if got.Status != model.OutboxPublished || got.Attempts != 2 || got.LastError != "" {
+		t.Fatalf("event = status %s, attempts %d, last_error %q; want published on the retry", got.Status, got.Attempts, got.LastError)
+	}
+	if len(publisher.Events()) != 1 {
+		t.Errorf("published %d events, want 1", len(publisher.Events()))
+	}
+}
+
+func TestRelayMarksFailedAfterMaxAttempts(t *testing.T) {
+	outbox := &memoryOutbox{}
+	publisher := NewMemoryPublisher()
+	publisher.Err = errors.New("broker down")
+	event := newEvent()
+	event.Attempts = 2
+	outbox.add(event)
+
+	newTestRelay(outbox, publisher, 3).drain(context.Background())
+
+	got := outbox.get(event.ID)
+	if got.Status != model.OutboxFailed || got.Attempts != 3 {
+		t.Fatalf("event = status %s, attempts %d; want failed after 3 attempts", got.Status, got.Attempts)
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := newTestRelay(&memoryOutbox{}, NewMemoryPublisher(), 10)
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 6: 4 * time.Second} {
+		if got := r.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
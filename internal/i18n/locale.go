@@ -0,0 +1,74 @@
+// locale.go
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Idioma por defecto cuando el cliente no pide uno soportado
+const DefaultLocale = "es"
+
+// Idiomas para los que el servicio tiene textos
+var SupportedLocales = []string{"es", "en", "pt"}
+
+// IsSupported indica si el idioma está entre los soportados
+func IsSupported(locale string) bool {
+	for _, l := range SupportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveLocale elige el idioma de la respuesta: primero el parámetro "lang",
+// luego la cabecera Accept-Language (respetando los pesos q) y por último el default
+func ResolveLocale(lang string, acceptLanguage string) string {
+	if l := normalize(lang); IsSupported(l) {
+		return l
+	}
+
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag = strings.TrimSpace(part[:i])
+			if v, ok := strings.CutPrefix(strings.TrimSpace(part[i+1:]), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{locale: normalize(tag), q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if IsSupported(c.locale) {
+			return c.locale
+		}
+	}
+	return DefaultLocale
+}
+
+// Reduce un tag como "pt-BR" o "en_US" a su idioma base en minúsculas
+func normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
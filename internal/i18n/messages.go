@@ -0,0 +1,170 @@
+// messages.go
+package i18n
+
+import "fmt"
+
+// Idioma de los mensajes en logs y en Error() de los errores
+const LogLocale = "en"
+
+// Textos de los mensajes de error por clave e idioma. Son formatos de fmt y reciben
+// los mismos argumentos en todos los idiomas.
+// Las claves "problem.<code>.title" y "problem.<code>.detail" son el título y el detalle
+// por defecto de cada código de error de las respuestas problem+json.
+var messages = map[string]map[string]string{
+	// Títulos y detalles por código de error
+	"problem.invalid_input.title":                {"es": "Datos inválidos", "en": "Invalid input", "pt": "Dados inválidos"},
+	"problem.invalid_input.detail":               {"es": "La petición tiene datos inválidos", "en": "The request contains invalid data", "pt": "A requisição contém dados inválidos"},
+	"problem.invalid_query.title":                {"es": "Consulta inválida", "en": "Invalid query", "pt": "Consulta inválida"},
+	"problem.invalid_query.detail":               {"es": "Los parámetros de la consulta son inválidos", "en": "The query parameters are invalid", "pt": "Os parâmetros da consulta são inválidos"},
+	"problem.unauthenticated.title":              {"es": "No autenticado", "en": "Unauthenticated", "pt": "Não autenticado"},
+	"problem.unauthenticated.detail":             {"es": "La petición requiere autenticación", "en": "The request requires authentication", "pt": "A requisição requer autenticação"},
+	"problem.invalid_token.title":                {"es": "Token inválido", "en": "Invalid token", "pt": "Token inválido"},
+	"problem.invalid_token.detail":               {"es": "El token es inválido o está vencido", "en": "The token is invalid or expired", "pt": "O token é inválido ou expirou"},
+	"problem.invalid_service_credentials.title":  {"es": "Credenciales de servicio inválidas", "en": "Invalid service credentials", "pt": "Credenciais de serviço inválidas"},
+	"problem.invalid_service_credentials.detail": {"es": "Las credenciales del servicio no son válidas", "en": "The service credentials are not valid", "pt": "As credenciais do serviço não são válidas"},
+	"problem.forbidden.title":                    {"es": "Prohibido", "en": "Forbidden", "pt": "Proibido"},
+	"problem.forbidden.detail":                   {"es": "No tenés permiso para esta operación", "en": "You are not allowed to perform this operation", "pt": "Você não tem permissão para esta operação"},
+	"problem.forbidden_transition.title":         {"es": "Transición prohibida", "en": "Forbidden transition", "pt": "Transição proibida"},
+	"problem.forbidden_transition.detail":        {"es": "Tu rol no puede aplicar esta transición", "en": "Your role cannot apply this transition", "pt": "Seu papel não pode aplicar esta transição"},
+	"problem.not_found.title":                    {"es": "No encontrado", "en": "Not found", "pt": "Não encontrado"},
+	"problem.not_found.detail":                   {"es": "El recurso no existe", "en": "The resource does not exist", "pt": "O recurso não existe"},
+	"problem.conflict.title":                     {"es": "Conflicto", "en": "Conflict", "pt": "Conflito"},
+	"problem.conflict.detail":                    {"es": "El cambio choca con el estado actual del recurso", "en": "The change conflicts with the current state of the resource", "pt": "A alteração conflita com o estado atual do recurso"},
+	"problem.duplicate.title":                    {"es": "Duplicado", "en": "Duplicate", "pt": "Duplicado"},
+	"problem.duplicate.detail":                   {"es": "El recurso ya existe", "en": "The resource already exists", "pt": "O recurso já existe"},
+	"problem.version_conflict.title":             {"es": "Conflicto de versión", "en": "Version conflict", "pt": "Conflito de versão"},
+	"problem.version_conflict.detail":            {"es": "El recurso cambió mientras se procesaba la petición; reintentá", "en": "The resource changed while the request was being processed; retry", "pt": "O recurso mudou enquanto a requisição era processada; tente novamente"},
+	"problem.status_in_use.title":                {"es": "Estado en uso", "en": "Status in use", "pt": "Status em uso"},
+	"problem.status_in_use.detail":               {"es": "El estado todavía lo usan órdenes", "en": "The status is still used by orders", "pt": "O status ainda é usado por pedidos"},
+	"problem.idempotency_in_progress.title":      {"es": "Petición en curso", "en": "Request in progress", "pt": "Requisição em andamento"},
+	"problem.idempotency_in_progress.detail":     {"es": "Otra petición con la misma Idempotency-Key todavía se está procesando", "en": "Another request with the same Idempotency-Key is still being processed", "pt": "Outra requisição com a mesma Idempotency-Key ainda está sendo processada"},
+	"problem.idempotency_key_reused.title":       {"es": "Idempotency-Key reutilizada", "en": "Idempotency-Key reused", "pt": "Idempotency-Key reutilizada"},
+	"problem.idempotency_key_reused.detail":      {"es": "La Idempotency-Key ya se usó con otra petición", "en": "The Idempotency-Key was already used with a different request", "pt": "A Idempotency-Key já foi usada com outra requisição"},
+	"problem.precondition_failed.title":          {"es": "Precondición fallida", "en": "Precondition failed", "pt": "Pré-condição falhou"},
+	"problem.precondition_failed.detail":         {"es": "La versión del estado de la orden no coincide con If-Match", "en": "The order status version does not match If-Match", "pt": "A versão do status do pedido não corresponde ao If-Match"},
+	"problem.terminal_state.title":               {"es": "Estado terminal", "en": "Terminal state", "pt": "Estado terminal"},
+	"problem.terminal_state.detail":              {"es": "La orden está en un estado terminal y no admite cambios", "en": "The order is in a terminal state and cannot change", "pt": "O pedido está em um estado terminal e não pode mudar"},
+	"problem.transition_not_allowed.title":       {"es": "Transición no permitida", "en": "Transition not allowed", "pt": "Transição não permitida"},
+	"problem.transition_not_allowed.detail":      {"es": "El catálogo no permite esta transición", "en": "The catalog does not allow this transition", "pt": "O catálogo não permite esta transição"},
+	"problem.shipping_locked.title":              {"es": "Dirección de envío bloqueada", "en": "Shipping address locked", "pt": "Endereço de entrega bloqueado"},
+	"problem.shipping_locked.detail":             {"es": "La orden ya se despachó y su dirección de envío no puede cambiar", "en": "The order has already shipped and its shipping address cannot change", "pt": "O pedido já foi despachado e seu endereço de entrega não pode mudar"},
+	"problem.status_archived.title":              {"es": "Estado archivado", "en": "Status archived", "pt": "Status arquivado"},
+	"problem.status_archived.detail":             {"es": "El estado está archivado y ya no puede asignarse", "en": "The status is archived and can no longer be assigned", "pt": "O status está arquivado e não pode mais ser atribuído"},
+	"problem.order_not_found.title":              {"es": "Orden inexistente", "en": "Order not found", "pt": "Pedido inexistente"},
+	"problem.order_not_found.detail":             {"es": "El servicio de órdenes no conoce la orden", "en": "The orders service does not know the order", "pt": "O serviço de pedidos não conhece o pedido"},
+	"problem.order_mismatch.title":               {"es": "La orden no coincide", "en": "Order mismatch", "pt": "O pedido não corresponde"},
+	"problem.order_mismatch.detail":              {"es": "Los datos no coinciden con la orden del servicio de órdenes", "en": "The data does not match the order in the orders service", "pt": "Os dados não correspondem ao pedido do serviço de pedidos"},
+	"problem.unavailable.title":                  {"es": "Servicio no disponible", "en": "Service unavailable", "pt": "Serviço indisponível"},
+	"problem.unavailable.detail":                 {"es": "Una dependencia del servicio no está disponible", "en": "A service dependency is unavailable", "pt": "Uma dependência do serviço está indisponível"},
+	"problem.orders_rejected.title":              {"es": "Consulta rechazada por el servicio de órdenes", "en": "Orders service rejected the request", "pt": "Consulta rejeitada pelo serviço de pedidos"},
+	"problem.orders_rejected.detail":             {"es": "El servicio de órdenes rechazó la consulta de la orden", "en": "The orders service rejected the order lookup", "pt": "O serviço de pedidos rejeitou a consulta do pedido"},
+	"problem.catalog_inconsistent.title":         {"es": "Catálogo inconsistente", "en": "Inconsistent catalog", "pt": "Catálogo inconsistente"},
+	"problem.catalog_inconsistent.detail":        {"es": "Al catálogo de estados le falta un estado necesario", "en": "The status catalog is missing a required status", "pt": "O catálogo de status não tem um status necessário"},
+	"problem.timeout.title":                      {"es": "Tiempo agotado", "en": "Timeout", "pt": "Tempo esgotado"},
+	"problem.timeout.detail":                     {"es": "La operación tardó demasiado", "en": "The operation timed out", "pt": "A operação demorou demais"},
+	"problem.internal_error.title":               {"es": "Error interno", "en": "Internal error", "pt": "Erro interno"},
+	"problem.internal_error.detail":              {"es": "Error interno del servidor", "en": "Internal server error", "pt": "Erro interno do servidor"},
+
+	// Autenticación y permisos
+	"auth.missing_header":              {"es": "Falta la cabecera Authorization", "en": "missing authorization header", "pt": "Falta o cabeçalho Authorization"},
+	"auth.invalid_token":               {"es": "Token inválido o vencido", "en": "invalid or expired token", "pt": "Token inválido ou expirado"},
+	"auth.unavailable":                 {"es": "El servicio de autenticación no está disponible", "en": "authentication service unavailable", "pt": "O serviço de autenticação está indisponível"},
+	"auth.admin_required":              {"es": "Se requieren privilegios de administrador", "en": "admin privileges required", "pt": "São necessários privilégios de administrador"},
+	"auth.not_owner":                   {"es": "No sos el dueño de la orden", "en": "not the owner of the order", "pt": "Você não é o dono do pedido"},
+	"service_auth.invalid_credentials": {"es": "Credenciales de servicio inválidas", "en": "invalid service credentials", "pt": "Credenciais de serviço inválidas"},
+	"service_auth.signature_expired":   {"es": "La firma del servicio está vencida", "en": "service signature expired", "pt": "A assinatura do serviço expirou"},
+
+	// Petición
+	"request.invalid_body":             {"es": "Cuerpo de la petición inválido", "en": "invalid body", "pt": "Corpo da requisição inválido"},
+	"request.invalid_body_detail":      {"es": "Cuerpo de la petición inválido: %v", "en": "invalid body: %v", "pt": "Corpo da requisição inválido: %v"},
+	"request.idempotency_key_too_long": {"es": "La Idempotency-Key es demasiado larga", "en": "idempotency key too long", "pt": "A Idempotency-Key é longa demais"},
+	"request.invalid_if_match":         {"es": "Cabecera If-Match inválida", "en": "invalid If-Match header", "pt": "Cabeçalho If-Match inválido"},
+
+	// Consultas
+	"query.missing_status_filter": {"es": "Falta el parámetro status o status_id", "en": "missing status or status_id param", "pt": "Falta o parâmetro status ou status_id"},
+	"query.invalid_cursor":        {"es": "Cursor inválido", "en": "invalid cursor", "pt": "Cursor inválido"},
+	"query.invalid_sort":          {"es": "sort debe ser created_at o updated_at", "en": "sort must be created_at or updated_at", "pt": "sort deve ser created_at ou updated_at"},
+	"query.invalid_order":         {"es": "order debe ser asc o desc", "en": "order must be asc or desc", "pt": "order deve ser asc ou desc"},
+	"query.invalid_limit":         {"es": "limit debe estar entre 1 y %d", "en": "limit must be between 1 and %d", "pt": "limit deve estar entre 1 e %d"},
+	"query.invalid_from":          {"es": "from debe ser una fecha RFC3339", "en": "from must be an RFC3339 date", "pt": "from deve ser uma data RFC3339"},
+	"query.invalid_to":            {"es": "to debe ser una fecha RFC3339", "en": "to must be an RFC3339 date", "pt": "to deve ser uma data RFC3339"},
+	"query.invalid_range":         {"es": "from debe ser anterior a to", "en": "from must be before to", "pt": "from deve ser anterior a to"},
+
+	// Catálogo de estados
+	"status.invalid_id":                   {"es": "Id de estado inválido", "en": "invalid status id", "pt": "Id de status inválido"},
+	"status.invalid_id_value":             {"es": "Id de estado inválido: '%s'", "en": "invalid status id '%s'", "pt": "Id de status inválido: '%s'"},
+	"status.invalid_new_id":               {"es": "Id del nuevo estado inválido", "en": "invalid new status id", "pt": "Id do novo status inválido"},
+	"status.not_found":                    {"es": "Estado no encontrado", "en": "status not found", "pt": "Status não encontrado"},
+	"status.id_not_in_catalog":            {"es": "El estado %s no existe en el catálogo", "en": "status id %s not found in catalog", "pt": "O status %s não existe no catálogo"},
+	"status.name_not_in_catalog":          {"es": "El estado '%s' no existe en el catálogo", "en": "status '%s' does not exist in catalog", "pt": "O status '%s' não existe no catálogo"},
+	"status.archived":                     {"es": "El estado '%s' está archivado", "en": "status '%s' is archived", "pt": "O status '%s' está arquivado"},
+	"catalog.status_exists":               {"es": "El estado ya existe en el catálogo", "en": "status already exists in catalog", "pt": "O status já existe no catálogo"},
+	"catalog.code_exists":                 {"es": "El código de estado '%s' ya existe en el catálogo", "en": "status code '%s' already exists in catalog", "pt": "O código de status '%s' já existe no catálogo"},
+	"catalog.invalid_category":            {"es": "Categoría inválida: '%s'", "en": "invalid category '%s'", "pt": "Categoria inválida: '%s'"},
+	"catalog.invalid_code":                {"es": "Código inválido '%s': usá minúsculas, dígitos y '_'", "en": "invalid code '%s': use lowercase letters, digits and '_'", "pt": "Código inválido '%s': use letras minúsculas, dígitos e '_'"},
+	"catalog.name_required":               {"es": "El nombre no puede estar vacío", "en": "name cannot be empty", "pt": "O nome não pode estar vazio"},
+	"catalog.terminal_with_transitions":   {"es": "Un estado terminal no puede tener transiciones salientes", "en": "a terminal status cannot have outgoing transitions", "pt": "Um status terminal não pode ter transições de saída"},
+	"catalog.archived_initial":            {"es": "Un estado archivado no puede ser el estado inicial", "en": "an archived status cannot be the initial status", "pt": "Um status arquivado não pode ser o status inicial"},
+	"catalog.unset_initial":               {"es": "Siempre tiene que haber un estado inicial: marcá otro estado como inicial", "en": "there must always be an initial status; mark another status as initial instead", "pt": "Sempre deve haver um status inicial: marque outro status como inicial"},
+	"catalog.initial_terminal":            {"es": "Un estado no puede ser inicial y terminal a la vez", "en": "a status cannot be both initial and terminal", "pt": "Um status não pode ser inicial e terminal ao mesmo tempo"},
+	"catalog.archive_initial":             {"es": "El estado inicial no puede archivarse", "en": "the initial status cannot be archived", "pt": "O status inicial não pode ser arquivado"},
+	"catalog.delete_initial":              {"es": "El estado inicial no puede eliminarse", "en": "the initial status cannot be deleted", "pt": "O status inicial não pode ser excluído"},
+	"catalog.status_in_use":               {"es": "El estado todavía lo usan %d órdenes; archivalo en su lugar", "en": "status is still used by orders (%d); archive it instead", "pt": "O status ainda é usado por %d pedidos; arquive-o em vez disso"},
+	"catalog.initial_missing":             {"es": "El catálogo no tiene un estado inicial", "en": "the catalog has no initial status", "pt": "O catálogo não tem um status inicial"},
+	"catalog.current_status_missing":      {"es": "El estado actual '%s' de la orden no existe en el catálogo", "en": "current status '%s' not found in catalog", "pt": "O status atual '%s' do pedido não existe no catálogo"},
+	"catalog.self_transition":             {"es": "Un estado no puede tener una transición a sí mismo", "en": "a status cannot transition to itself", "pt": "Um status não pode ter uma transição para si mesmo"},
+	"catalog.duplicated_transition":       {"es": "Transición duplicada a '%s'", "en": "duplicated transition to '%s'", "pt": "Transição duplicada para '%s'"},
+	"catalog.invalid_transition_target":   {"es": "Destino de transición inválido: '%s'", "en": "invalid transition to_id '%s'", "pt": "Destino de transição inválido: '%s'"},
+	"catalog.transition_target_not_found": {"es": "El destino de transición %s no existe en el catálogo", "en": "transition target %s not found in catalog", "pt": "O destino de transição %s não existe no catálogo"},
+	"catalog.transition_target_archived":  {"es": "El destino de transición '%s' está archivado", "en": "transition target '%s' is archived", "pt": "O destino de transição '%s' está arquivado"},
+	"catalog.unknown_role":                {"es": "Rol desconocido en la transición: '%s'", "en": "unknown role '%s' in transition", "pt": "Papel desconhecido na transição: '%s'"},
+
+	// Estados de órdenes
+	"order_status.invalid_id":             {"es": "Id de estado de orden inválido", "en": "invalid order status id", "pt": "Id de status do pedido inválido"},
+	"order_status.not_found":              {"es": "Estado de orden no encontrado", "en": "order status not found", "pt": "Status do pedido não encontrado"},
+	"order_status.exists":                 {"es": "La orden ya tiene un estado", "en": "order status already exists for this order", "pt": "O pedido já tem um status"},
+	"order_status.terminal":               {"es": "No se puede cambiar el estado desde el estado terminal '%s'", "en": "cannot change status from terminal state '%s'", "pt": "Não é possível mudar o status a partir do estado terminal '%s'"},
+	"order_status.transition_not_allowed": {"es": "La transición de '%s' a '%s' no está permitida", "en": "transition from '%s' to '%s' is not allowed", "pt": "A transição de '%s' para '%s' não é permitida"},
+	"order_status.transition_forbidden":   {"es": "El rol '%s' no puede cambiar el estado de '%s' a '%s'", "en": "role '%s' cannot change status from '%s' to '%s'", "pt": "O papel '%s' não pode mudar o status de '%s' para '%s'"},
+	"order_status.shipping_locked":        {"es": "La dirección de envío no puede cambiar con la orden en '%s'", "en": "shipping address cannot change while the order is '%s'", "pt": "O endereço de entrega não pode mudar com o pedido em '%s'"},
+
+	// Dirección de envío
+	"shipping.required_field": {"es": "El campo de envío '%s' es obligatorio", "en": "shipping field '%s' is required", "pt": "O campo de envio '%s' é obrigatório"},
+
+	// Servicio de órdenes
+	"orders.order_not_found":   {"es": "La orden %s no existe en el servicio de órdenes", "en": "order %s not found in orders service", "pt": "O pedido %s não existe no serviço de pedidos"},
+	"orders.user_mismatch":     {"es": "La orden %s no pertenece al usuario", "en": "order %s does not belong to the user", "pt": "O pedido %s não pertence ao usuário"},
+	"orders.shipping_mismatch": {"es": "El campo de envío '%s' no coincide con la orden", "en": "shipping field '%s' does not match the order", "pt": "O campo de envio '%s' não corresponde ao pedido"},
+	"orders.rejected":          {"es": "El servicio de órdenes rechazó la consulta de la orden %s", "en": "orders service rejected the lookup of order %s", "pt": "O serviço de pedidos rejeitou a consulta do pedido %s"},
+	"orders.unavailable":       {"es": "El servicio de órdenes no está disponible", "en": "orders service unavailable", "pt": "O serviço de pedidos está indisponível"},
+
+	// Avisos al servicio de órdenes
+	"order_sync.invalid_id":     {"es": "Id de aviso inválido", "en": "invalid sync id", "pt": "Id de aviso inválido"},
+	"order_sync.not_found":      {"es": "Aviso no encontrado", "en": "sync not found", "pt": "Aviso não encontrado"},
+	"order_sync.invalid_status": {"es": "status debe ser pending, succeeded o failed", "en": "status must be pending, succeeded or failed", "pt": "status deve ser pending, succeeded ou failed"},
+
+	// Webhooks
+	"webhook.invalid_url":   {"es": "URL de webhook inválida", "en": "invalid webhook url", "pt": "URL de webhook inválida"},
+	"webhook.invalid_id":    {"es": "Id de webhook inválido", "en": "invalid webhook id", "pt": "Id de webhook inválido"},
+	"webhook.invalid_event": {"es": "Evento de webhook desconocido: '%s'", "en": "unknown webhook event: '%s'", "pt": "Evento de webhook desconhecido: '%s'"},
+	"webhook.not_found":     {"es": "Webhook no encontrado", "en": "webhook not found", "pt": "Webhook não encontrado"},
+	"delivery.invalid_id":   {"es": "Id de entrega inválido", "en": "invalid delivery id", "pt": "Id de entrega inválido"},
+	"delivery.not_found":    {"es": "Entrega no encontrada", "en": "delivery not found", "pt": "Entrega não encontrada"},
+}
+
+// Message devuelve el texto del mensaje en el idioma pedido con los argumentos aplicados.
+// Si no hay texto para ese idioma usa el idioma por defecto; si la clave no existe, devuelve la clave.
+func Message(locale string, key string, args ...interface{}) string {
+	texts, ok := messages[key]
+	if !ok {
+		return key
+	}
+	text, ok := texts[locale]
+	if !ok {
+		text = texts[DefaultLocale]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
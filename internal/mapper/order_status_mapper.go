@@ -4,6 +4,7 @@ package mapper
 import (
 	"order-status-service/internal/dto"
 	"order-status-service/internal/model"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -23,14 +24,68 @@ func ToOrderStatusEntity(req dto.CreateOrderStatusRequest) model.OrderStatus {
 // Convierte una entidad de base de datos a DTO
 func ToOrderStatusDTO(entity model.OrderStatus) dto.OrderStatusDTO {
 	return dto.OrderStatusDTO{
-		ID:        entity.ID.Hex(),
-		OrderID:   entity.OrderID,
-		UserID:    entity.UserID,
-		Status:    entity.Status,
-		UpdatedAt: entity.UpdatedAt,
+		ID:              entity.ID.Hex(),
+		OrderID:         entity.OrderID,
+		UserID:          entity.UserID,
+		SellerIDs:       entity.SellerIDs,
+		StatusID:        entity.StatusID.Hex(),
+		Status:          entity.Status,
+		Shipping:        ToShippingDTO(entity.Shipping),
+		History:         ToStatusEntryDTOs(entity.History),
+		ShippingHistory: ToShippingRevisionDTOs(entity.ShippingHistory),
+		Version:         entity.Version,
+		CreatedAt:       entity.CreatedAt,
+		UpdatedAt:       entity.UpdatedAt,
 	}
 }
 
+func ToStatusEntryDTO(e model.StatusEntry) dto.StatusEntryDTO {
+	return dto.StatusEntryDTO{
+		ID:     e.ID.Hex(),
+		Status: e.Status,
+		UserID: e.UserID,
+		Role:   e.Role,
+		Reason: e.Reason,
+		At:     e.At,
+	}
+}
+
+func ToStatusEntryDTOs(entries []model.StatusEntry) []dto.StatusEntryDTO {
+	dtos := make([]dto.StatusEntryDTO, len(entries))
+	for i, e := range entries {
+		dtos[i] = ToStatusEntryDTO(e)
+	}
+	return dtos
+}
+
+func ToShippingRevisionDTOs(revisions []model.ShippingRevision) []dto.ShippingRevisionDTO {
+	dtos := make([]dto.ShippingRevisionDTO, len(revisions))
+	for i, r := range revisions {
+		dtos[i] = dto.ShippingRevisionDTO{
+			ID:     r.ID.Hex(),
+			Old:    ToShippingDTO(r.Old),
+			New:    ToShippingDTO(r.New),
+			UserID: r.UserID,
+			Role:   r.Role,
+			At:     r.At,
+		}
+	}
+	return dtos
+}
+
+func ToStatusHistoryItemDTOs(records []model.StatusHistoryRecord) []dto.StatusHistoryItemDTO {
+	dtos := make([]dto.StatusHistoryItemDTO, len(records))
+	for i, r := range records {
+		dtos[i] = dto.StatusHistoryItemDTO{
+			OrderStatusID: r.OrderStatusID.Hex(),
+			OrderID:       r.OrderID,
+			OwnerID:       r.OwnerID,
+			Entry:         ToStatusEntryDTO(r.Entry),
+		}
+	}
+	return dtos
+}
+
 // Convierte múltiples entidades de base de datos a DTOs
 func ToOrderStatusDTOs(entities []model.OrderStatus) []dto.OrderStatusDTO {
 	dtos := make([]dto.OrderStatusDTO, len(entities))
@@ -40,6 +95,24 @@ func ToOrderStatusDTOs(entities []model.OrderStatus) []dto.OrderStatusDTO {
 	return dtos
 }
 
+// Completa código y etiqueta localizada del estado a partir del catálogo
+func LocalizeStatus(d *dto.OrderStatusDTO, status model.StatusCatalog, locale string, fallback string) {
+	d.StatusCode = status.Code
+	d.Status = status.Label(locale, fallback)
+}
+
+func ToShippingDTO(s model.ShippingInfo) dto.ShippingDTO {
+	return dto.ShippingDTO{
+		AddressLine1: s.AddressLine1,
+		AddressLine2: s.AddressLine2,
+		City:         s.City,
+		Province:     s.Province,
+		Country:      s.Country,
+		Zipcode:      s.Zipcode,
+		Comments:     s.Comments,
+	}
+}
+
 func ToShippingEntity(s dto.ShippingDTO) model.ShippingInfo {
 	return model.ShippingInfo{
 		AddressLine1: s.AddressLine1,
@@ -51,3 +124,25 @@ func ToShippingEntity(s dto.ShippingDTO) model.ShippingInfo {
 		Comments:     s.Comments,
 	}
 }
+
+// Aplica sobre la dirección actual los campos enviados en la corrección
+func ApplyShippingUpdate(current model.ShippingInfo, req dto.UpdateShippingRequest) model.ShippingInfo {
+	fields := []struct {
+		value *string
+		dst   *string
+	}{
+		{req.AddressLine1, &current.AddressLine1},
+		{req.AddressLine2, &current.AddressLine2},
+		{req.City, &current.City},
+		{req.Province, &current.Province},
+		{req.Country, &current.Country},
+		{req.Zipcode, &current.Zipcode},
+		{req.Comments, &current.Comments},
+	}
+	for _, f := range fields {
+		if f.value != nil {
+			*f.dst = strings.TrimSpace(*f.value)
+		}
+	}
+	return current
+}
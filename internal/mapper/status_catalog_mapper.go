@@ -0,0 +1,23 @@
+// status_catalog_mapper.go
+package mapper
+
+import (
+	"fmt"
+	"order-status-service/internal/dto"
+	"order-status-service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Convierte las transiciones recibidas por la API a entidades del catálogo
+func ToTransitionEntities(dtos []dto.TransitionDTO) ([]model.StatusTransition, error) {
+	transitions := make([]model.StatusTransition, 0, len(dtos))
+	for _, t := range dtos {
+		toID, err := primitive.ObjectIDFromHex(t.ToID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transition to_id '%s'", t.ToID)
+		}
+		transitions = append(transitions, model.StatusTransition{ToID: toID, Roles: t.Roles})
+	}
+	return transitions, nil
+}
@@ -2,29 +2,18 @@
 package middleware
 
 import (
-	"fmt"
-	"net/http"
+	"order-status-service/internal/policy"
+	"order-status-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 func AdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		perms := c.GetStringSlice("userPermissions")
-		isAdmin := false
-		for _, p := range perms {
-			if p == "admin" {
-				isAdmin = true
-				break
-			}
-		}
-		if !isAdmin {
-			c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
-			c.Abort()
+		if policy.ResolveRole(c.GetStringSlice("userPermissions")) != policy.RoleAdmin {
+			AbortWithError(c, service.NewError(policy.ErrForbidden, "auth.admin_required"))
 			return
 		}
 		c.Next()
-		fmt.Println("PERMISSIONS:", perms)
 	}
-
 }
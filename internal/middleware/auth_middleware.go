@@ -2,7 +2,9 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
+	"errors"
+	"log"
 	"order-status-service/internal/service"
 	"strings"
 
@@ -14,18 +16,24 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
-			c.Abort()
+			AbortWithError(c, service.NewError(service.ErrUnauthenticated, "auth.missing_header"))
 			return
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		token = strings.TrimSpace(token)
-		user, err := authService.ValidateToken(token)
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
-			c.Abort()
+		user, err := authService.ValidateToken(c.Request.Context(), token)
+		switch {
+		case errors.Is(err, service.ErrInvalidToken):
+			AbortWithError(c, service.NewError(service.ErrInvalidToken, "auth.invalid_token"))
+			return
+		case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+			AbortWithError(c, err)
+			return
+		case err != nil:
+			// el token no se pudo validar: no es culpa del cliente
+			log.Printf("⚠️ Token validation failed: %v", err)
+			AbortWithError(c, service.NewError(service.ErrUnavailable, "auth.unavailable"))
 			return
 		}
 
@@ -0,0 +1,155 @@
+// errors.go
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"order-status-service/internal/i18n"
+	"order-status-service/internal/policy"
+	"order-status-service/internal/repository"
+	"order-status-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Código que usa nginx cuando el cliente cierra la conexión antes de recibir la respuesta.
+// El cliente ya no lo ve, pero queda registrado en logs y métricas.
+const StatusClientClosedRequest = 499
+
+// Tipo de contenido de las respuestas de error (RFC 7807)
+const problemContentType = "application/problem+json"
+
+// Prefijo del campo type: cada código de error es un tipo de problema
+const problemTypePrefix = "urn:order-status-service:problem:"
+
+// Cuerpo de todas las respuestas de error (RFC 7807). title y detail están en el idioma del
+// cliente; code es estable y es lo que deben usar los clientes para decidir qué hacer.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+	Code     string `json:"code"`
+}
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// Traducción de los errores a respuestas HTTP. Se usa la primera que coincida,
+// por lo que las clases más específicas van antes que las generales.
+var errorMappings = []errorMapping{
+	{service.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+	{repository.ErrVersionConflict, http.StatusConflict, "version_conflict"},
+	{repository.ErrDuplicateKey, http.StatusConflict, "duplicate"},
+	{service.ErrStatusInUse, http.StatusConflict, "status_in_use"},
+	{service.ErrIdempotencyInProgress, http.StatusConflict, "idempotency_in_progress"},
+	{service.ErrConflict, http.StatusConflict, "conflict"},
+	{service.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{service.ErrTerminalState, http.StatusUnprocessableEntity, "terminal_state"},
+	{service.ErrTransitionNotAllowed, http.StatusUnprocessableEntity, "transition_not_allowed"},
+	{service.ErrStatusArchived, http.StatusUnprocessableEntity, "status_archived"},
+	{service.ErrShippingLocked, http.StatusUnprocessableEntity, "shipping_locked"},
+	{service.ErrOrderNotFound, http.StatusUnprocessableEntity, "order_not_found"},
+	{service.ErrOrderMismatch, http.StatusUnprocessableEntity, "order_mismatch"},
+	{service.ErrForbiddenTransition, http.StatusForbidden, "forbidden_transition"},
+	{policy.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{service.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+	{service.ErrInvalidServiceCredentials, http.StatusUnauthorized, "invalid_service_credentials"},
+	{service.ErrUnauthenticated, http.StatusUnauthorized, "unauthenticated"},
+	{service.ErrInvalidQuery, http.StatusBadRequest, "invalid_query"},
+	{service.ErrInvalidInput, http.StatusBadRequest, "invalid_input"},
+	{service.ErrNotFound, http.StatusNotFound, "not_found"},
+	{mongo.ErrNoDocuments, http.StatusNotFound, "not_found"},
+	{service.ErrOrdersRejected, http.StatusBadGateway, "orders_rejected"},
+	{service.ErrCatalogInconsistent, http.StatusInternalServerError, "catalog_inconsistent"},
+	{service.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+}
+
+// ErrorHandler responde el último error que registraron los handlers con c.Error, si todavía
+// no escribieron una respuesta. Debe registrarse antes que el resto de los middlewares.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		writeError(c)
+	}
+}
+
+// AbortWithError registra el error para ErrorHandler y corta la cadena de handlers
+func AbortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Escribe la respuesta del último error registrado. Además de ErrorHandler la usan los
+// middlewares que necesitan la respuesta final antes de terminar (p. ej. Idempotency).
+func writeError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	ginErr := c.Errors.Last()
+	err := ginErr.Err
+
+	locale := requestLocale(c)
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(c.Request.Context().Err(), context.Canceled):
+		c.AbortWithStatus(StatusClientClosedRequest)
+		return
+	case repository.IsTimeout(err):
+		writeProblem(c, http.StatusGatewayTimeout, "timeout", i18n.Message(locale, "problem.timeout.detail"))
+		return
+	case ginErr.IsType(gin.ErrorTypeBind):
+		writeProblem(c, http.StatusBadRequest, "invalid_input", i18n.Message(locale, "request.invalid_body_detail", err))
+		return
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			writeProblem(c, m.status, m.code, problemDetail(err, m.code, locale))
+			return
+		}
+	}
+
+	// errores inesperados: el detalle queda en el log, no en la respuesta
+	log.Printf("❌ %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	writeProblem(c, http.StatusInternalServerError, "internal_error", i18n.Message(locale, "problem.internal_error.detail"))
+}
+
+// Escribe la respuesta problem+json; el título sale del código en el idioma de la petición
+func writeProblem(c *gin.Context, status int, code string, detail string) {
+	// gin respeta el Content-Type ya definido al serializar el JSON
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, problem{
+		Type:     problemTypePrefix + code,
+		Title:    i18n.Message(requestLocale(c), "problem."+code+".title"),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+	})
+}
+
+// Los errores de negocio traen su propio mensaje; el resto (sentinels como ErrPreconditionFailed)
+// usa el detalle por defecto del código
+func problemDetail(err error, code string, locale string) string {
+	var svcErr *service.Error
+	if errors.As(err, &svcErr) {
+		return svcErr.Message(locale)
+	}
+	return i18n.Message(locale, "problem."+code+".detail")
+}
+
+// Idioma de la respuesta de error. Lo resuelve el middleware Locale; si la petición se cortó
+// antes (p. ej. en un middleware previo) se resuelve acá con las mismas reglas.
+func requestLocale(c *gin.Context) string {
+	if locale := c.GetString("locale"); locale != "" {
+		return locale
+	}
+	return i18n.ResolveLocale(c.Query("lang"), c.GetHeader("Accept-Language"))
+}
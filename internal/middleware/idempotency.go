@@ -0,0 +1,116 @@
+// idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"order-status-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Cabeceras de la respuesta que no se guardan para los reintentos: las calcula el servidor en
+// cada respuesta o las agrega este middleware
+var unreplayedHeaders = map[string]bool{
+	"Content-Type":        true,
+	"Content-Length":      true,
+	"Date":                true,
+	"Connection":          true,
+	"Transfer-Encoding":   true,
+	"Idempotent-Replayed": true,
+}
+
+// Guarda una copia de lo que el handler escribe en la respuesta
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware que implementa la cabecera Idempotency-Key: un reintento con la misma
+// clave y la misma petición recibe la respuesta original sin volver a ejecutarla.
+// Debe ir después de AuthMiddleware (o ServiceAuth) cuando la ruta es autenticada, para acotar la clave al usuario o servicio.
+func Idempotency(idem *service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			AbortWithError(c, service.NewError(service.ErrInvalidInput, "request.idempotency_key_too_long"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			AbortWithError(c, service.NewError(service.ErrInvalidInput, "request.invalid_body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		caller := c.GetString("userID")
+		if caller == "" {
+			caller = "service:" + c.GetString("serviceName")
+		}
+		scope := c.Request.Method + " " + c.FullPath() + " " + caller
+		id, replay, err := idem.Begin(c.Request.Context(), scope, key, c.Request.Method, c.Request.URL.Path, c.GetHeader("If-Match"), body)
+		switch {
+		case err != nil:
+			AbortWithError(c, err)
+			return
+		case replay != nil:
+			for name, values := range replay.Headers {
+				for _, v := range values {
+					c.Writer.Header().Add(name, v)
+				}
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(replay.StatusCode, replay.ContentType, replay.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// los errores se responden acá para poder guardar la respuesta final
+		writeError(c)
+
+		// La respuesta se guarda (o la clave se libera) aunque el cliente ya se haya desconectado
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// Los errores del servidor no se recuerdan: el cliente debe poder reintentar.
+		// Tampoco una petición abandonada (499): puede no haberse completado.
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || status == StatusClientClosedRequest {
+			if err := idem.Release(ctx, id); err != nil {
+				log.Printf("⚠️ Error al liberar la clave de idempotencia: %v", err)
+			}
+			return
+		}
+		headers := map[string][]string{}
+		for name, values := range recorder.Header() {
+			if !unreplayedHeaders[http.CanonicalHeaderKey(name)] {
+				headers[name] = values
+			}
+		}
+		if err := idem.Complete(ctx, id, status, recorder.Header().Get("Content-Type"), headers, recorder.body.Bytes()); err != nil {
+			log.Printf("⚠️ Error al guardar la respuesta idempotente: %v", err)
+		}
+	}
+}
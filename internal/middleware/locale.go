@@ -0,0 +1,16 @@
+// locale.go
+package middleware
+
+import (
+	"order-status-service/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware que resuelve el idioma de la respuesta y lo guarda en el contexto
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("locale", i18n.ResolveLocale(c.Query("lang"), c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+// service_auth.go
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"order-status-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware para rutas que solo pueden llamar otros microservicios.
+// Acepta, en este orden: certificado cliente (mTLS), API key o firma HMAC de la petición.
+// Guarda el nombre del servicio en el contexto ("serviceName").
+func ServiceAuth(serviceAuth *service.ServiceAuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := authenticateService(c, serviceAuth)
+		switch {
+		case errors.Is(err, service.ErrServiceSignatureExpired):
+			AbortWithError(c, service.NewError(service.ErrInvalidServiceCredentials, "service_auth.signature_expired"))
+			return
+		case err != nil:
+			AbortWithError(c, service.NewError(service.ErrInvalidServiceCredentials, "service_auth.invalid_credentials"))
+			return
+		}
+
+		c.Set("serviceName", identity.Name)
+		c.Set("serviceAuthMethod", identity.Method)
+		c.Next()
+	}
+}
+
+func authenticateService(c *gin.Context, serviceAuth *service.ServiceAuthService) (service.ServiceIdentity, error) {
+	if tls := c.Request.TLS; tls != nil && len(tls.PeerCertificates) > 0 {
+		if identity, err := serviceAuth.AuthenticateCertificate(tls.PeerCertificates[0]); err == nil {
+			return identity, nil
+		}
+	}
+
+	if key := c.GetHeader(service.ServiceKeyHeader); key != "" {
+		return serviceAuth.AuthenticateAPIKey(key)
+	}
+
+	if signature := c.GetHeader(service.ServiceSignatureHeader); signature != "" {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return service.ServiceIdentity{}, err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		return serviceAuth.VerifySignature(
+			c.GetHeader(service.ServiceNameHeader),
+			c.GetHeader(service.ServiceTimestampHeader),
+			signature,
+			c.Request.Method,
+			c.Request.URL.Path,
+			body,
+		)
+	}
+
+	return service.ServiceIdentity{}, service.ErrInvalidServiceCredentials
+}
@@ -0,0 +1,97 @@
+// migration.go
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration es un cambio de esquema versionado. Up debe ser idempotente:
+// si el proceso se corta antes de registrarla, se vuelve a ejecutar en el próximo arranque.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Registro de una migración aplicada
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Runner aplica las migraciones pendientes y registra cuáles ya se aplicaron
+type Runner struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewRunner(db *mongo.Database) *Runner {
+	return &Runner{
+		db:         db,
+		collection: db.Collection("schema_migrations"),
+	}
+}
+
+// Run aplica en orden las migraciones cuya versión todavía no está registrada
+func (r *Runner) Run(ctx context.Context, migrations []Migration) error {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+
+	for _, m := range pending {
+		log.Printf("🔧 Aplicando migración %d: %s", m.Version, m.Description)
+		if err := m.Up(ctx, r.db); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		_, err := r.collection.InsertOne(ctx, appliedMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now(),
+		})
+		// otra instancia pudo registrarla al mismo tiempo
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("recording migration %d: %w", m.Version, err)
+		}
+	}
+
+	if len(pending) == 0 {
+		log.Println("✅ Esquema actualizado, no hay migraciones pendientes")
+	}
+	return nil
+}
+
+// Versiones ya aplicadas
+func (r *Runner) applied(ctx context.Context) (map[int]bool, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []appliedMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = true
+	}
+	return applied, nil
+}
@@ -0,0 +1,282 @@
+// migrations.go
+package migration
+
+import (
+	"context"
+	"fmt"
+	"order-status-service/internal/model"
+	"order-status-service/internal/repository"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All devuelve las migraciones del servicio. Nunca modificar una ya publicada:
+// agregar una nueva con la siguiente versión.
+func All() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "indexes on order_statuses",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				if err := requireUnique(ctx, db, "order_statuses", "order_id"); err != nil {
+					return err
+				}
+				return createIndexes("order_statuses",
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "order_id", Value: 1}},
+						Options: options.Index().SetName("order_id_unique").SetUnique(true),
+					},
+					mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+					mongo.IndexModel{Keys: bson.D{{Key: "status_id", Value: 1}}, Options: options.Index().SetName("status_id")},
+					mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}}, Options: options.Index().SetName("status")},
+				)(ctx, db)
+			},
+		},
+		{
+			Version:     2,
+			Description: "indexes on statuses_catalog",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				if err := requireUnique(ctx, db, "statuses_catalog", "name"); err != nil {
+					return err
+				}
+				return createIndexes("statuses_catalog",
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "name", Value: 1}},
+						Options: options.Index().SetName("name_unique").SetUnique(true),
+					},
+					// los estados creados antes de que existiera "code" no lo tienen todavía
+					mongo.IndexModel{
+						Keys: bson.D{{Key: "code", Value: 1}},
+						Options: options.Index().SetName("code_unique").SetUnique(true).
+							SetPartialFilterExpression(bson.M{"code": bson.M{"$type": "string"}}),
+					},
+				)(ctx, db)
+			},
+		},
+		{
+			Version:     3,
+			Description: "TTL index on idempotency_keys",
+			Up: createIndexes("idempotency_keys",
+				mongo.IndexModel{
+					Keys: bson.D{{Key: "created_at", Value: 1}},
+					Options: options.Index().SetName("created_at_ttl").
+						SetExpireAfterSeconds(int32(repository.IdempotencyKeyTTL.Seconds())),
+				},
+			),
+		},
+		{
+			Version:     4,
+			Description: "indexes for the outbox relay and webhook deliveries",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				if err := createIndexes("outbox_events",
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+						Options: options.Index().SetName("status_next_attempt_at"),
+					},
+				)(ctx, db); err != nil {
+					return err
+				}
+				return createIndexes("webhook_deliveries",
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+						Options: options.Index().SetName("status_next_attempt_at"),
+					},
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}},
+						Options: options.Index().SetName("subscription_id_created_at"),
+					},
+				)(ctx, db)
+			},
+		},
+		{
+			Version:     5,
+			Description: "indexes for paginated order status listings",
+			Up: createIndexes("order_statuses",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+					Options: options.Index().SetName("created_at_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}},
+					Options: options.Index().SetName("updated_at_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+					Options: options.Index().SetName("user_id_created_at_id"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}},
+					Options: options.Index().SetName("user_id_updated_at_id"),
+				},
+			),
+		},
+		{
+			Version:     6,
+			Description: "indexes for history queries",
+			Up: createIndexes("order_statuses",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "history.at", Value: -1}},
+					Options: options.Index().SetName("history_at"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "history.user_id", Value: 1}, {Key: "history.at", Value: -1}},
+					Options: options.Index().SetName("history_user_id_at"),
+				},
+			),
+		},
+		{
+			Version:     7,
+			Description: "order_statuses seller_ids index",
+			Up: createIndexes("order_statuses",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "seller_ids", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("seller_ids_created_at"),
+				},
+			),
+		},
+		{
+			Version:     8,
+			Description: "indexes for the orders sync worker",
+			Up: createIndexes("order_syncs",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+					Options: options.Index().SetName("status_next_attempt_at"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("status_created_at"),
+				},
+			),
+		},
+		{
+			Version:     9,
+			Description: "one webhook delivery per outbox event and subscription",
+			Up: createIndexes("webhook_deliveries",
+				// las entregas creadas antes de que el relay las generara no tienen event_id
+				mongo.IndexModel{
+					Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "subscription_id", Value: 1}},
+					Options: options.Index().SetName("event_id_subscription_id_unique").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"event_id": bson.M{"$type": "objectId"}}),
+				},
+			),
+		},
+		{
+			Version:     10,
+			Description: "one orders sync per outbox event",
+			Up: createIndexes("order_syncs",
+				// los avisos creados antes de que el relay los generara no tienen event_id
+				mongo.IndexModel{
+					Keys: bson.D{{Key: "event_id", Value: 1}},
+					Options: options.Index().SetName("event_id_unique").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"event_id": bson.M{"$type": "objectId"}}),
+				},
+			),
+		},
+		{
+			Version:     11,
+			Description: "a single initial status in statuses_catalog",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				if err := requireSingleInitial(ctx, db); err != nil {
+					return err
+				}
+				return createIndexes("statuses_catalog",
+					mongo.IndexModel{
+						Keys: bson.D{{Key: "is_initial", Value: 1}},
+						Options: options.Index().SetName("is_initial_unique").SetUnique(true).
+							SetPartialFilterExpression(bson.M{"is_initial": true}),
+					},
+				)(ctx, db)
+			},
+		},
+		{
+			Version:     12,
+			Description: "webhook subscriptions created before events keep order.status_changed only",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection("webhook_subscriptions").UpdateMany(ctx,
+					bson.M{"$or": bson.A{
+						bson.M{"events": bson.M{"$exists": false}},
+						bson.M{"events": nil},
+						bson.M{"events": bson.M{"$size": 0}},
+					}},
+					bson.M{"$set": bson.M{"events": bson.A{model.EventStatusChanged}}},
+				)
+				return err
+			},
+		},
+	}
+}
+
+// Cuántos valores repetidos se listan como máximo en el error de requireUnique
+const maxReportedDuplicates = 20
+
+// Antes de crear un índice único, verifica que no haya documentos con el mismo valor en field.
+// Si los hay, falla listando los valores repetidos en lugar del error genérico de Mongo.
+func requireUnique(ctx context.Context, db *mongo.Database, collection string, field string) error {
+	cursor, err := db.Collection(collection).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: maxReportedDuplicates}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var duplicates []struct {
+		Value interface{} `bson:"_id"`
+		Count int         `bson:"count"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	values := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		values[i] = fmt.Sprintf("%v (%d)", d.Value, d.Count)
+	}
+	return fmt.Errorf("cannot create unique index on %s.%s, duplicated values (up to %d shown): %s; remove the duplicates (see \"Migraciones de esquema\" in the README) and restart",
+		collection, field, maxReportedDuplicates, strings.Join(values, ", "))
+}
+
+// Antes de crear el índice único parcial sobre is_initial, verifica que haya a lo sumo un estado
+// inicial. Si hay más, falla listando sus nombres.
+func requireSingleInitial(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("statuses_catalog").Find(ctx, bson.M{"is_initial": true},
+		options.Find().SetProjection(bson.M{"name": 1}).SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var initial []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &initial); err != nil {
+		return err
+	}
+	if len(initial) <= 1 {
+		return nil
+	}
+
+	names := make([]string, len(initial))
+	for i, s := range initial {
+		names[i] = s.Name
+	}
+	return fmt.Errorf("cannot create unique index on statuses_catalog.is_initial, several initial statuses: %s; leave is_initial: true on a single one and restart",
+		strings.Join(names, ", "))
+}
+
+// Crea los índices indicados en una colección (CreateMany es idempotente si no cambian)
+func createIndexes(collection string, indexes ...mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
@@ -0,0 +1,22 @@
+// idempotency_record.go
+package model
+
+import "time"
+
+// Respuesta guardada para una Idempotency-Key, para devolverla tal cual ante un reintento
+type IdempotencyRecord struct {
+	// Alcance (método, ruta y llamador) + clave enviada por el cliente
+	ID          string `bson:"_id" json:"id"`
+	Key         string `bson:"key" json:"key"`
+	Scope       string `bson:"scope" json:"scope"`
+	RequestHash string `bson:"request_hash" json:"request_hash"`
+	Completed   bool   `bson:"completed" json:"completed"`
+	// Mientras no se completa, la clave está reservada hasta este momento
+	LockedUntil time.Time `bson:"locked_until" json:"locked_until"`
+	StatusCode  int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	ContentType string    `bson:"content_type,omitempty" json:"content_type,omitempty"`
+	// Cabeceras de la respuesta original (p. ej. ETag), para devolverlas en los reintentos
+	Headers   map[string][]string `bson:"headers,omitempty" json:"headers,omitempty"`
+	Body      []byte              `bson:"body,omitempty" json:"body,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
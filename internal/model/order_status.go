@@ -16,6 +16,16 @@ type ShippingInfo struct {
 	Comments     string `bson:"comments,omitempty" json:"comments,omitempty"`
 }
 
+// Corrección de la dirección de envío: el valor anterior, el nuevo, quién la hizo y cuándo
+type ShippingRevision struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Old    ShippingInfo       `bson:"old" json:"old"`
+	New    ShippingInfo       `bson:"new" json:"new"`
+	UserID string             `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Role   string             `bson:"role,omitempty" json:"role,omitempty"`
+	At     time.Time          `bson:"at" json:"at"`
+}
+
 type StatusEntry struct {
 	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Status string             `bson:"status" json:"status"`
@@ -29,11 +39,25 @@ type OrderStatus struct {
 	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	OrderID string             `bson:"order_id" json:"order_id"`
 	UserID  string             `bson:"user_id" json:"user_id"`
+	// Vendedores asignados a la orden (pueden verla y cambiar su estado)
+	SellerIDs []string `bson:"seller_ids,omitempty" json:"seller_ids,omitempty"`
 	// Nuevo: referenciamos el estado por id y mantenemos un nombre legible
-	StatusID  primitive.ObjectID `bson:"status_id" json:"status_id"`
-	Status    string             `bson:"status" json:"status"`
-	Shipping  ShippingInfo       `bson:"shipping" json:"shipping"`
-	History   []StatusEntry      `bson:"history" json:"history"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	StatusID primitive.ObjectID `bson:"status_id" json:"status_id"`
+	Status   string             `bson:"status" json:"status"`
+	Shipping ShippingInfo       `bson:"shipping" json:"shipping"`
+	History  []StatusEntry      `bson:"history" json:"history"`
+	// Correcciones de la dirección de envío, separadas del historial de estados
+	ShippingHistory []ShippingRevision `bson:"shipping_history,omitempty" json:"shipping_history,omitempty"`
+	// Se incrementa en cada cambio de estado o de dirección (control de concurrencia optimista)
+	Version   int64     `bson:"version" json:"version"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Entrada de historial junto con la orden a la que pertenece (consultas sobre el historial)
+type StatusHistoryRecord struct {
+	OrderStatusID primitive.ObjectID `bson:"order_status_id" json:"order_status_id"`
+	OrderID       string             `bson:"order_id" json:"order_id"`
+	OwnerID       string             `bson:"owner_id" json:"owner_id"`
+	Entry         StatusEntry        `bson:"entry" json:"entry"`
 }
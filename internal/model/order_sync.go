@@ -0,0 +1,35 @@
+// order_sync.go
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Acción que debe ejecutar el microservicio de órdenes cuando la orden llega a un estado terminal
+type OrderSyncAction string
+
+const (
+	OrderSyncCancel       OrderSyncAction = "cancel"        // liberar stock y pago reservados
+	OrderSyncRefundNeeded OrderSyncAction = "refund_needed" // la orden fue rechazada: hay que devolver el pago
+	OrderSyncFulfilled    OrderSyncAction = "fulfilled"     // la orden se entregó
+)
+
+// Aviso pendiente al microservicio de órdenes, con el registro de cada intento.
+// Reutiliza los estados e intentos de las entregas de webhooks.
+type OrderSync struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// Evento del outbox que originó el aviso (único: el relay puede volver a publicarlo)
+	EventID  primitive.ObjectID      `bson:"event_id,omitempty" json:"event_id,omitempty"`
+	OrderID  string                  `bson:"order_id" json:"order_id"`
+	Action   OrderSyncAction         `bson:"action" json:"action"`
+	Payload  OrderStatusEventPayload `bson:"payload" json:"payload"`
+	Status   DeliveryStatus          `bson:"status" json:"status"`
+	Attempts []WebhookAttempt        `bson:"attempts" json:"attempts"`
+	// Intentos del ciclo actual; se reinicia al reintentar manualmente
+	Retries       int       `bson:"retries" json:"retries"`
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
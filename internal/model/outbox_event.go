@@ -0,0 +1,52 @@
+// outbox_event.go
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipos de eventos de dominio emitidos por el servicio
+const (
+	EventStatusInitialized = "order.status_initialized"
+	EventStatusChanged     = "order.status_changed"
+	EventShippingUpdated   = "order.shipping_updated"
+)
+
+// Estado de publicación de un evento del outbox
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	OutboxFailed    OutboxStatus = "failed"
+)
+
+// Datos del cambio que viajan en el evento. Los cambios de estado traen Entry y los de
+// dirección de envío traen Shipping; ambos informan el estado actual de la orden.
+type OrderStatusEventPayload struct {
+	OrderStatusID    string            `bson:"order_status_id" json:"order_status_id"`
+	OrderID          string            `bson:"order_id" json:"order_id"`
+	UserID           string            `bson:"user_id" json:"user_id"`
+	StatusID         string            `bson:"status_id" json:"status_id"`
+	Status           string            `bson:"status" json:"status"`
+	PreviousStatusID string            `bson:"previous_status_id,omitempty" json:"previous_status_id,omitempty"`
+	PreviousStatus   string            `bson:"previous_status,omitempty" json:"previous_status,omitempty"`
+	Entry            *StatusEntry      `bson:"entry,omitempty" json:"entry,omitempty"`
+	Shipping         *ShippingRevision `bson:"shipping,omitempty" json:"shipping,omitempty"`
+}
+
+// Evento pendiente de publicar, escrito en la misma transacción que el cambio que lo origina
+type OutboxEvent struct {
+	ID            primitive.ObjectID      `bson:"_id,omitempty" json:"id"`
+	Type          string                  `bson:"type" json:"type"`
+	AggregateID   string                  `bson:"aggregate_id" json:"aggregate_id"`
+	Payload       OrderStatusEventPayload `bson:"payload" json:"payload"`
+	Status        OutboxStatus            `bson:"status" json:"status"`
+	Attempts      int                     `bson:"attempts" json:"attempts"`
+	LastError     string                  `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt time.Time               `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time               `bson:"created_at" json:"created_at"`
+	PublishedAt   *time.Time              `bson:"published_at,omitempty" json:"published_at,omitempty"`
+}
@@ -7,9 +7,70 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Categoría semántica de un estado, independiente de su nombre
+type StatusCategory string
+
+const (
+	CategoryPending    StatusCategory = "pending"
+	CategoryInProgress StatusCategory = "in_progress"
+	CategoryShipped    StatusCategory = "shipped"
+	CategoryDelivered  StatusCategory = "delivered"
+	CategoryCancelled  StatusCategory = "cancelled"
+	CategoryRejected   StatusCategory = "rejected"
+)
+
+// IsValid indica si la categoría es una de las conocidas
+func (c StatusCategory) IsValid() bool {
+	switch c {
+	case CategoryPending, CategoryInProgress, CategoryShipped,
+		CategoryDelivered, CategoryCancelled, CategoryRejected:
+		return true
+	}
+	return false
+}
+
+// IsPreShipment indica si la orden todavía no se despachó (la dirección de envío puede corregirse)
+func (c StatusCategory) IsPreShipment() bool {
+	return c == CategoryPending || c == CategoryInProgress
+}
+
+// Transición permitida desde un estado del catálogo hacia otro,
+// junto con los roles que pueden ejecutarla
+type StatusTransition struct {
+	ToID  primitive.ObjectID `bson:"to_id" json:"to_id"`
+	Roles []string           `bson:"roles" json:"roles"`
+}
+
 // Modelo de los estados base del catálogo
 type StatusCatalog struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name      string             `bson:"name" json:"name"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// Código estable para uso máquina; el nombre y las etiquetas pueden cambiar
+	Code string `bson:"code" json:"code"`
+	Name string `bson:"name" json:"name"`
+	// Etiquetas visibles por idioma ("es", "en", "pt", ...)
+	Labels      map[string]string  `bson:"labels,omitempty" json:"labels,omitempty"`
+	IsInitial   bool               `bson:"is_initial" json:"is_initial"`
+	IsTerminal  bool               `bson:"is_terminal" json:"is_terminal"`
+	Category    StatusCategory     `bson:"category" json:"category"`
+	Transitions []StatusTransition `bson:"transitions" json:"transitions"`
+	// Un estado archivado se conserva para las órdenes que ya lo tienen,
+	// pero no puede asignarse ni usarse como destino de nuevas transiciones
+	Archived   bool       `bson:"archived" json:"archived"`
+	ArchivedAt *time.Time `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+	// Última vez que se asignó a una orden
+	LastAssignedAt *time.Time `bson:"last_assigned_at,omitempty" json:"last_assigned_at,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// Label devuelve la etiqueta del estado en el idioma pedido, con respaldo
+// en el idioma por defecto y finalmente en el nombre
+func (s StatusCatalog) Label(locale string, fallback string) string {
+	if label, ok := s.Labels[locale]; ok && label != "" {
+		return label
+	}
+	if label, ok := s.Labels[fallback]; ok && label != "" {
+		return label
+	}
+	return s.Name
 }
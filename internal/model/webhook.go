@@ -0,0 +1,61 @@
+// webhook.go
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Suscripción de un partner a los cambios de estado de las órdenes
+type WebhookSubscription struct {
+	ID  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL string             `bson:"url" json:"url"`
+	// Clave para firmar las entregas (HMAC-SHA256); nunca se expone en los listados
+	Secret string `bson:"secret" json:"-"`
+	// Estados del catálogo que disparan el webhook; vacío = todos
+	StatusIDs []primitive.ObjectID `bson:"status_ids" json:"status_ids"`
+	// Eventos que recibe (ver WebhookEvents); las suscripciones anteriores a los eventos de envío
+	// reciben solo order.status_changed
+	Events    []string  `bson:"events" json:"events"`
+	Active    bool      `bson:"active" json:"active"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Eventos del outbox a los que se puede suscribir un webhook
+var WebhookEvents = []string{EventStatusChanged, EventShippingUpdated}
+
+// Estado de una entrega de webhook
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Resultado de un intento de entrega
+type WebhookAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// Entrega de un evento a una suscripción, con el registro de cada intento
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	// Evento del outbox que originó la entrega (una entrega por evento y suscripción)
+	EventID  primitive.ObjectID      `bson:"event_id,omitempty" json:"event_id,omitempty"`
+	URL      string                  `bson:"url" json:"url"`
+	Event    string                  `bson:"event" json:"event"`
+	Payload  OrderStatusEventPayload `bson:"payload" json:"payload"`
+	Status   DeliveryStatus          `bson:"status" json:"status"`
+	Attempts []WebhookAttempt        `bson:"attempts" json:"attempts"`
+	// Intentos del ciclo actual; se reinicia al reenviar manualmente
+	Retries       int       `bson:"retries" json:"retries"`
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
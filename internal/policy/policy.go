@@ -0,0 +1,128 @@
+// policy.go
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Role es el rol con el que un actor opera sobre una orden
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleSeller Role = "seller"
+	RoleClient Role = "client"
+	// Cambios hechos por el propio servicio u otros microservicios
+	RoleSystem Role = "system"
+)
+
+// Se devuelve cuando el actor no puede ver ni modificar la orden
+var ErrForbidden = errors.New("forbidden")
+
+// Motivos concretos de ErrForbidden al resolver el rol sobre una orden
+var (
+	ErrNotOwner          = fmt.Errorf("%w: not the owner of the order", ErrForbidden)
+	ErrSellerNotAssigned = fmt.Errorf("%w: order is not assigned to this seller", ErrForbidden)
+)
+
+// Actor es quien realiza la operación
+type Actor struct {
+	ID   string
+	Role Role
+}
+
+// Datos de la orden que importan para decidir permisos
+type Order struct {
+	OwnerID   string
+	SellerIDs []string
+}
+
+// NewActor arma el actor a partir de los permisos del usuario autenticado
+func NewActor(id string, permissions []string) Actor {
+	return Actor{ID: id, Role: ResolveRole(permissions)}
+}
+
+// ResolveRole devuelve el rol de mayor privilegio entre los permisos: admin > seller > client.
+// El rol system nunca sale de los permisos de un usuario.
+func ResolveRole(permissions []string) Role {
+	switch {
+	case slices.Contains(permissions, string(RoleAdmin)):
+		return RoleAdmin
+	case slices.Contains(permissions, string(RoleSeller)):
+		return RoleSeller
+	default:
+		return RoleClient
+	}
+}
+
+// RolesFor resuelve con qué roles puede actuar el actor sobre una orden concreta, del de mayor
+// privilegio al de menor: admin y system sobre cualquier orden, seller solo sobre las órdenes a las
+// que está asignado y el dueño de la orden como client. Un vendedor asignado que además es el dueño
+// actúa como seller y como client, así puede aplicar también las transiciones reservadas al dueño.
+func RolesFor(actor Actor, order Order) ([]Role, error) {
+	var roles []Role
+	switch actor.Role {
+	case RoleAdmin, RoleSystem:
+		roles = append(roles, actor.Role)
+	case RoleSeller:
+		if actor.ID != "" && slices.Contains(order.SellerIDs, actor.ID) {
+			roles = append(roles, RoleSeller)
+		}
+	}
+	if actor.ID != "" && actor.ID == order.OwnerID {
+		roles = append(roles, RoleClient)
+	}
+	if len(roles) > 0 {
+		return roles, nil
+	}
+	if actor.Role == RoleSeller {
+		return nil, ErrSellerNotAssigned
+	}
+	return nil, ErrNotOwner
+}
+
+// RoleFor resuelve el rol principal del actor sobre una orden, el primero de RolesFor
+func RoleFor(actor Actor, order Order) (Role, error) {
+	roles, err := RolesFor(actor, order)
+	if err != nil {
+		return "", err
+	}
+	return roles[0], nil
+}
+
+// RoleForShipping resuelve con qué rol el actor corrige la dirección de envío de una orden:
+// admin sobre cualquier orden y el dueño como client. Los vendedores no pueden cambiarla.
+func RoleForShipping(actor Actor, order Order) (Role, error) {
+	if actor.Role == RoleAdmin {
+		return RoleAdmin, nil
+	}
+	if actor.ID != "" && actor.ID == order.OwnerID {
+		return RoleClient, nil
+	}
+	return "", ErrNotOwner
+}
+
+// CanView indica si el actor puede ver la orden
+func CanView(actor Actor, order Order) bool {
+	_, err := RoleFor(actor, order)
+	return err == nil
+}
+
+// CanTransition indica si el rol puede aplicar una transición habilitada para roles.
+// system puede aplicar cualquier transición existente del catálogo.
+func CanTransition(role Role, roles []string) bool {
+	return role == RoleSystem || slices.Contains(roles, string(role))
+}
+
+// TransitionRole devuelve el primero de los roles del actor (ver RolesFor) que puede aplicar una
+// transición habilitada para roles, o false si ninguno puede
+func TransitionRole(actorRoles []Role, roles []string) (Role, bool) {
+	for _, role := range actorRoles {
+		if CanTransition(role, roles) {
+			return role, true
+		}
+	}
+	return "", false
+}
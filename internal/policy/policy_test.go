@@ -0,0 +1,140 @@
+// policy_test.go
+package policy
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// Orden de referencia: del cliente "owner", asignada al vendedor "seller-1"
+var order = Order{OwnerID: "owner", SellerIDs: []string{"seller-1"}}
+
+// Actores frente a la orden de referencia y el rol con el que operan sobre ella
+var ownershipCases = []struct {
+	name    string
+	actor   Actor
+	role    Role
+	wantErr error
+}{
+	{"admin", Actor{ID: "admin-1", Role: RoleAdmin}, RoleAdmin, nil},
+	{"system", Actor{ID: "orders", Role: RoleSystem}, RoleSystem, nil},
+	{"owner", Actor{ID: "owner", Role: RoleClient}, RoleClient, nil},
+	{"assigned seller", Actor{ID: "seller-1", Role: RoleSeller}, RoleSeller, nil},
+	{"unrelated seller", Actor{ID: "seller-2", Role: RoleSeller}, "", ErrSellerNotAssigned},
+	{"seller buying as owner", Actor{ID: "owner", Role: RoleSeller}, RoleClient, nil},
+	{"client on someone else's order", Actor{ID: "other", Role: RoleClient}, "", ErrNotOwner},
+	{"client without id", Actor{Role: RoleClient}, "", ErrNotOwner},
+}
+
+func TestResolveRole(t *testing.T) {
+	tests := []struct {
+		permissions []string
+		want        Role
+	}{
+		{nil, RoleClient},
+		{[]string{"user"}, RoleClient},
+		{[]string{"seller"}, RoleSeller},
+		{[]string{"user", "admin"}, RoleAdmin},
+		{[]string{"seller", "admin"}, RoleAdmin},
+		{[]string{"system"}, RoleClient},
+	}
+	for _, tt := range tests {
+		if got := ResolveRole(tt.permissions); got != tt.want {
+			t.Errorf("ResolveRole(%v) = %q, want %q", tt.permissions, got, tt.want)
+		}
+	}
+}
+
+func TestRoleFor(t *testing.T) {
+	for _, tt := range ownershipCases {
+		t.Run(tt.name, func(t *testing.T) {
+			role, err := RoleFor(tt.actor, order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RoleFor error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && !errors.Is(err, ErrForbidden) {
+				t.Errorf("RoleFor error %v does not wrap ErrForbidden", err)
+			}
+			if role != tt.role {
+				t.Errorf("RoleFor role = %q, want %q", role, tt.role)
+			}
+			if got := CanView(tt.actor, order); got != (tt.wantErr == nil) {
+				t.Errorf("CanView = %v, want %v", got, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestRolesFor(t *testing.T) {
+	// orden de un vendedor que compra en su propia tienda: es dueño y vendedor asignado
+	ownOrder := Order{OwnerID: "seller-1", SellerIDs: []string{"seller-1"}}
+	tests := []struct {
+		name  string
+		actor Actor
+		order Order
+		want  []Role
+	}{
+		{"owner", Actor{ID: "owner", Role: RoleClient}, order, []Role{RoleClient}},
+		{"assigned seller", Actor{ID: "seller-1", Role: RoleSeller}, order, []Role{RoleSeller}},
+		{"assigned seller owning the order", Actor{ID: "seller-1", Role: RoleSeller}, ownOrder, []Role{RoleSeller, RoleClient}},
+		{"admin owning the order", Actor{ID: "owner", Role: RoleAdmin}, order, []Role{RoleAdmin, RoleClient}},
+		{"unrelated seller", Actor{ID: "seller-2", Role: RoleSeller}, order, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, err := RolesFor(tt.actor, tt.order)
+			if (err == nil) != (tt.want != nil) || !slices.Equal(roles, tt.want) {
+				t.Errorf("RolesFor = %v, %v; want %v", roles, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		actor  []Role
+		roles  []string
+		want   Role
+		wantOK bool
+	}{
+		{"first role that can apply it", []Role{RoleSeller, RoleClient}, []string{"admin", "seller"}, RoleSeller, true},
+		{"owner-only transition as seller and owner", []Role{RoleSeller, RoleClient}, []string{"client"}, RoleClient, true},
+		{"no role can apply it", []Role{RoleSeller}, []string{"client"}, "", false},
+		{"system applies any transition", []Role{RoleSystem}, nil, RoleSystem, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, ok := TransitionRole(tt.actor, tt.roles)
+			if role != tt.want || ok != tt.wantOK {
+				t.Errorf("TransitionRole = %q, %v; want %q, %v", role, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRoleForShipping(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   Actor
+		role    Role
+		wantErr error
+	}{
+		{"admin", Actor{ID: "admin-1", Role: RoleAdmin}, RoleAdmin, nil},
+		{"owner", Actor{ID: "owner", Role: RoleClient}, RoleClient, nil},
+		{"seller buying as owner", Actor{ID: "owner", Role: RoleSeller}, RoleClient, nil},
+		{"assigned seller", Actor{ID: "seller-1", Role: RoleSeller}, "", ErrNotOwner},
+		{"unrelated seller", Actor{ID: "seller-2", Role: RoleSeller}, "", ErrNotOwner},
+		{"system", Actor{ID: "orders", Role: RoleSystem}, "", ErrNotOwner},
+		{"client on someone else's order", Actor{ID: "other", Role: RoleClient}, "", ErrNotOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, err := RoleForShipping(tt.actor, order)
+			if !errors.Is(err, tt.wantErr) || role != tt.role {
+				t.Errorf("RoleForShipping = %q, %v; want %q, %v", role, err, tt.role, tt.wantErr)
+			}
+		})
+	}
+}
@@ -22,9 +22,7 @@ func NewCatalogRepository(db *mongo.Database) *CatalogRepository {
 	}
 }
 
-func (r *CatalogRepository) GetAll() ([]model.StatusCatalog, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (r *CatalogRepository) GetAll(ctx context.Context) ([]model.StatusCatalog, error) {
 
 	cursor, err := r.Collection.Find(ctx, bson.M{})
 	if err != nil {
@@ -39,22 +37,16 @@ func (r *CatalogRepository) GetAll() ([]model.StatusCatalog, error) {
 	return results, nil
 }
 
-func (r *CatalogRepository) Count() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (r *CatalogRepository) Count(ctx context.Context) (int64, error) {
 	return r.Collection.CountDocuments(ctx, bson.M{})
 }
 
-func (r *CatalogRepository) InsertMany(defaults []interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (r *CatalogRepository) InsertMany(ctx context.Context, defaults []interface{}) error {
 	_, err := r.Collection.InsertMany(ctx, defaults)
 	return err
 }
 
-func (r *CatalogRepository) ExistsByName(name string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (r *CatalogRepository) ExistsByName(ctx context.Context, name string) (bool, error) {
 	count, err := r.Collection.CountDocuments(ctx, bson.M{"name": name})
 	if err != nil {
 		return false, err
@@ -62,9 +54,29 @@ func (r *CatalogRepository) ExistsByName(name string) (bool, error) {
 	return count > 0, nil
 }
 
+func (r *CatalogRepository) ExistsByCode(ctx context.Context, code string) (bool, error) {
+	count, err := r.Collection.CountDocuments(ctx, bson.M{"code": code})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// InsertOne stores a new status. If it is the initial status, the previous initial status is
+// unmarked in the same transaction, so there is always exactly one.
 func (r *CatalogRepository) InsertOne(ctx context.Context, status model.StatusCatalog) error {
-	_, err := r.Collection.InsertOne(ctx, status)
-	return err
+	if !status.IsInitial {
+		_, err := r.Collection.InsertOne(ctx, status)
+		return wrapDuplicateKey(err)
+	}
+	err := withTransaction(ctx, r.Collection.Database().Client(), func(sc mongo.SessionContext) error {
+		if err := r.clearInitial(sc, status.ID); err != nil {
+			return err
+		}
+		_, err := r.Collection.InsertOne(sc, status)
+		return err
+	})
+	return wrapDuplicateKey(err)
 }
 
 func (r *CatalogRepository) ExistsByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
@@ -87,17 +99,106 @@ func (r *CatalogRepository) FindByName(ctx context.Context, name string) (model.
 	return res, err
 }
 
-func (r *CatalogRepository) GetByID(id string) (*model.StatusCatalog, error) {
+func (r *CatalogRepository) GetByID(ctx context.Context, id string) (*model.StatusCatalog, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
 
 	var result model.StatusCatalog
-	err = r.Collection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&result)
+	err = r.Collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&result)
 	if err != nil {
 		return nil, err
 	}
 
 	return &result, nil
 }
+
+// UpdateTransitions reemplaza las transiciones salientes de un estado
+func (r *CatalogRepository) UpdateTransitions(ctx context.Context, id primitive.ObjectID, transitions []model.StatusTransition) error {
+	res, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"transitions": transitions}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetDefaultsIfMissing completa campos de estados creados antes de que existieran,
+// sin pisar los valores que ya tengan
+func (r *CatalogRepository) SetDefaultsIfMissing(ctx context.Context, id primitive.ObjectID, defaults bson.M) error {
+	set := bson.M{}
+	for field, value := range defaults {
+		set[field] = bson.M{"$ifNull": bson.A{"$" + field, bson.M{"$literal": value}}}
+	}
+	_, err := r.Collection.UpdateByID(ctx, id, mongo.Pipeline{{{Key: "$set", Value: set}}})
+	return err
+}
+
+// FindInitial devuelve el estado marcado como inicial
+func (r *CatalogRepository) FindInitial(ctx context.Context) (model.StatusCatalog, error) {
+	var res model.StatusCatalog
+	err := r.Collection.FindOne(ctx, bson.M{"is_initial": true}).Decode(&res)
+	return res, err
+}
+
+// clearInitial desmarca como inicial a todos los estados salvo al indicado
+func (r *CatalogRepository) clearInitial(ctx context.Context, exceptID primitive.ObjectID) error {
+	filter := bson.M{"is_initial": true, "_id": bson.M{"$ne": exceptID}}
+	_, err := r.Collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"is_initial": false}})
+	return err
+}
+
+// Update aplica los campos indicados a un estado del catálogo
+func (r *CatalogRepository) Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	res, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": fields})
+	if err != nil {
+		return wrapDuplicateKey(err)
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UpdateInitial aplica los campos indicados y marca el estado como el único inicial,
+// desmarcando al anterior en la misma transacción
+func (r *CatalogRepository) UpdateInitial(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	fields["is_initial"] = true
+	return withTransaction(ctx, r.Collection.Database().Client(), func(sc mongo.SessionContext) error {
+		if err := r.clearInitial(sc, id); err != nil {
+			return err
+		}
+		return r.Update(sc, id, fields)
+	})
+}
+
+// Unarchive vuelve a habilitar un estado archivado
+func (r *CatalogRepository) Unarchive(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set":   bson.M{"archived": false, "updated_at": time.Now()},
+		"$unset": bson.M{"archived_at": ""},
+	})
+	return err
+}
+
+// Delete elimina un estado del catálogo
+func (r *CatalogRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// PullTransitionsTo quita de todos los estados las transiciones hacia el estado indicado
+func (r *CatalogRepository) PullTransitionsTo(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{"$pull": bson.M{"transitions": bson.M{"to_id": id}}}
+	_, err := r.Collection.UpdateMany(ctx, bson.M{"transitions.to_id": id}, update)
+	return err
+}
@@ -0,0 +1,65 @@
+// errors.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrDuplicateKey is returned when a write violates a unique index
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrStatusNotAssignable is returned when the catalog status being assigned to an order was
+// archived or deleted after it was validated
+var ErrStatusNotAssignable = errors.New("status is archived or no longer exists")
+
+// ErrVersionConflict is returned when a conditional update finds that the document
+// changed since it was read
+var ErrVersionConflict = errors.New("order status was modified concurrently")
+
+// withTransaction runs fn inside a Mongo session transaction (requires a replica set).
+// Transient errors such as write conflicts are retried by the driver.
+func withTransaction(ctx context.Context, client *mongo.Client, fn func(sc mongo.SessionContext) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// wrapDuplicateKey turns Mongo duplicate-key errors into ErrDuplicateKey, keeping the original message
+func wrapDuplicateKey(err error) error {
+	if err != nil && mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %v", ErrDuplicateKey, err)
+	}
+	return err
+}
+
+// onlyDuplicateKeys reports whether every write of a failed unordered bulk insert
+// was rejected by a unique index, i.e. the documents were already stored
+func onlyDuplicateKeys(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, we := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(we) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsTimeout reports whether err comes from an operation that ran past its deadline,
+// either the context's or a driver/server timeout
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err)
+}
@@ -0,0 +1,122 @@
+// history_query.go
+package repository
+
+import (
+	"context"
+	"order-status-service/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const historySortField = "history.at"
+
+// HistoryQuery filters the entries of the embedded history array, optionally scoped to one order
+type HistoryQuery struct {
+	OrderStatusID *primitive.ObjectID
+	OrderID       string
+	Role          string
+	ActorID       string
+	Status        string
+	From          *time.Time // inclusive
+	To            *time.Time // exclusive
+
+	Ascending bool
+	Limit     int64
+	Cursor    string
+}
+
+// FindHistory returns one page of history entries across orders, ordered by time,
+// plus the cursor for the next page ("" when there are no more results)
+func (r *OrderStatusRepository) FindHistory(ctx context.Context, q HistoryQuery) ([]model.StatusHistoryRecord, string, error) {
+	// filtro a nivel documento: descarta órdenes sin entradas candidatas antes del $unwind
+	docFilter := bson.M{}
+	if q.OrderStatusID != nil {
+		docFilter["_id"] = *q.OrderStatusID
+	}
+	if q.OrderID != "" {
+		docFilter["order_id"] = q.OrderID
+	}
+
+	entry := bson.M{}
+	if q.Role != "" {
+		entry["role"] = q.Role
+	}
+	if q.ActorID != "" {
+		entry["user_id"] = q.ActorID
+	}
+	if q.Status != "" {
+		entry["status"] = q.Status
+	}
+	if q.From != nil || q.To != nil {
+		rng := bson.M{}
+		if q.From != nil {
+			rng["$gte"] = *q.From
+		}
+		if q.To != nil {
+			rng["$lt"] = *q.To
+		}
+		entry["at"] = rng
+	}
+	if len(entry) > 0 {
+		docFilter["history"] = bson.M{"$elemMatch": entry}
+	}
+
+	// mismo filtro sobre cada entrada ya desanidada
+	and := bson.A{}
+	for field, cond := range entry {
+		and = append(and, bson.M{"history." + field: cond})
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, historySortField, q.Ascending)
+		if err != nil {
+			return nil, "", err
+		}
+		and = append(and, keysetFilter(c, "history._id"))
+	}
+	entryFilter := bson.M{}
+	if len(and) > 0 {
+		entryFilter["$and"] = and
+	}
+
+	direction := -1
+	if q.Ascending {
+		direction = 1
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: docFilter}},
+		{{Key: "$unwind", Value: "$history"}},
+		{{Key: "$match", Value: entryFilter}},
+		{{Key: "$sort", Value: bson.D{{Key: historySortField, Value: direction}, {Key: "history._id", Value: direction}}}},
+		// se pide un elemento extra para saber si hay otra página
+		{{Key: "$limit", Value: q.Limit + 1}},
+		{{Key: "$project", Value: bson.M{
+			"_id":             0,
+			"order_status_id": "$_id",
+			"order_id":        1,
+			"owner_id":        "$user_id",
+			"entry":           "$history",
+		}}},
+	}
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	results := []model.StatusHistoryRecord{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if int64(len(results)) > q.Limit {
+		results = results[:q.Limit]
+		last := results[len(results)-1]
+		next = encodeCursor(pageCursor{Sort: historySortField, Asc: q.Ascending, Value: last.Entry.At, ID: last.Entry.ID})
+	}
+	return results, next, nil
+}
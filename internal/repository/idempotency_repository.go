@@ -0,0 +1,83 @@
+// idempotency_repository.go
+package repository
+
+import (
+	"context"
+	"order-status-service/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// How long an Idempotency-Key is remembered (TTL index on created_at)
+const IdempotencyKeyTTL = 24 * time.Hour
+
+type IdempotencyRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewIdempotencyRepository(db *mongo.Database) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		Collection: db.Collection("idempotency_keys"),
+	}
+}
+
+// Reserve inserts a new in-progress record. If the key already exists it returns
+// the stored record and reserved=false.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	_, err := r.Collection.InsertOne(ctx, rec)
+	if err == nil {
+		return rec, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return model.IdempotencyRecord{}, false, err
+	}
+
+	var existing model.IdempotencyRecord
+	if err := r.Collection.FindOne(ctx, bson.M{"_id": rec.ID}).Decode(&existing); err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+	return existing, false, nil
+}
+
+// TakeOver reserves again an in-progress key whose lease expired (its request never finished),
+// as long as it belongs to the same request. It returns mongo.ErrNoDocuments if the key was
+// completed or taken over in the meantime. Records stored before leases existed count as expired.
+func (r *IdempotencyRepository) TakeOver(ctx context.Context, id string, requestHash string, lockedUntil time.Time) error {
+	filter := bson.M{
+		"_id":          id,
+		"request_hash": requestHash,
+		"completed":    false,
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$lte": time.Now()}},
+			bson.M{"locked_until": bson.M{"$exists": false}},
+		},
+	}
+	res, err := r.Collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"locked_until": lockedUntil}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Complete stores the response of a reserved key
+func (r *IdempotencyRepository) Complete(ctx context.Context, id string, statusCode int, contentType string, headers map[string][]string, body []byte) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"completed":    true,
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"headers":      headers,
+		"body":         body,
+	}})
+	return err
+}
+
+// Release frees a reserved key so that the request can be retried
+func (r *IdempotencyRepository) Release(ctx context.Context, id string) error {
+	_, err := r.Collection.DeleteOne(ctx, bson.M{"_id": id, "completed": false})
+	return err
+}
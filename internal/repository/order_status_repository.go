@@ -2,25 +2,54 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"order-status-service/internal/model"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrderStatusRepository struct {
 	Collection *mongo.Collection
+	catalog    *mongo.Collection
+	outbox     *OutboxRepository
 }
 
 func NewOrderStatusRepository(db *mongo.Database) *OrderStatusRepository {
 	return &OrderStatusRepository{
 		Collection: db.Collection("order_statuses"),
+		catalog:    db.Collection("statuses_catalog"),
+		outbox:     NewOutboxRepository(db),
 	}
 }
 
-// Create inserts a new OrderStatus document
+// withTransaction runs fn inside a Mongo session transaction (requires a replica set)
+func (r *OrderStatusRepository) withTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	return withTransaction(ctx, r.Collection.Database().Client(), fn)
+}
+
+// claimStatus records that a catalog status is being assigned, inside the transaction that
+// assigns it. The write fails if the status was archived (or deleted) meanwhile, and it conflicts
+// with a concurrent archive, so DeleteStatus never counts the orders before this one commits.
+func (r *OrderStatusRepository) claimStatus(sc mongo.SessionContext, statusID primitive.ObjectID) error {
+	res, err := r.catalog.UpdateOne(sc,
+		bson.M{"_id": statusID, "archived": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"last_assigned_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrStatusNotAssignable
+	}
+	return nil
+}
+
+// Create inserts a new OrderStatus document and its order.status_initialized event
 func (r *OrderStatusRepository) Create(ctx context.Context, status model.OrderStatus) error {
 	if status.ID.IsZero() {
 		status.ID = primitive.NewObjectID()
@@ -30,8 +59,35 @@ func (r *OrderStatusRepository) Create(ctx context.Context, status model.OrderSt
 		status.CreatedAt = now
 	}
 	status.UpdatedAt = now
-	_, err := r.Collection.InsertOne(ctx, status)
-	return err
+	if status.Version == 0 {
+		status.Version = 1
+	}
+
+	payload := model.OrderStatusEventPayload{
+		OrderStatusID: status.ID.Hex(),
+		OrderID:       status.OrderID,
+		UserID:        status.UserID,
+		StatusID:      status.StatusID.Hex(),
+		Status:        status.Status,
+	}
+	if len(status.History) > 0 {
+		payload.Entry = &status.History[len(status.History)-1]
+	}
+
+	err := r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		if err := r.claimStatus(sc, status.StatusID); err != nil {
+			return err
+		}
+		if _, err := r.Collection.InsertOne(sc, status); err != nil {
+			return err
+		}
+		return r.outbox.Insert(sc, model.OutboxEvent{
+			Type:        model.EventStatusInitialized,
+			AggregateID: status.ID.Hex(),
+			Payload:     payload,
+		})
+	})
+	return wrapDuplicateKey(err)
 }
 
 // FindByID retrieves an OrderStatus by its ObjectID
@@ -41,17 +97,32 @@ func (r *OrderStatusRepository) FindByID(ctx context.Context, id primitive.Objec
 	return res, err
 }
 
-// ExistsByOrderID checks if there's already a OrderStatus for an order_id
-func (r *OrderStatusRepository) ExistsByOrderID(ctx context.Context, orderID string) (bool, error) {
-	count, err := r.Collection.CountDocuments(ctx, bson.M{"order_id": orderID})
-	if err != nil {
-		return false, err
+// FindByOrderID retrieves the OrderStatus of an order; returns mongo.ErrNoDocuments if there is none
+func (r *OrderStatusRepository) FindByOrderID(ctx context.Context, orderID string) (model.OrderStatus, error) {
+	var res model.OrderStatus
+	err := r.Collection.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&res)
+	return res, err
+}
+
+// versionFilter matches the expected version; documents created before versioning count as version 0
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
 	}
-	return count > 0, nil
+	return version
 }
 
-// UpdateStatusWithEntry atomically updates current status and pushes a history entry
-func (r *OrderStatusRepository) UpdateStatusWithEntry(ctx context.Context, id primitive.ObjectID, statusID primitive.ObjectID, statusName string, entry model.StatusEntry) error {
+// UpdateStatusWithEntry atomically updates current status, pushes a history entry
+// and writes the order.status_changed event in the same transaction.
+// The update only applies if the document still has the version and status it had when
+// current was read; otherwise ErrVersionConflict is returned. If the target status was archived
+// after it was validated, ErrStatusNotAssignable is returned.
+func (r *OrderStatusRepository) UpdateStatusWithEntry(ctx context.Context, current model.OrderStatus, statusID primitive.ObjectID, statusName string, entry model.StatusEntry) (model.OrderStatus, error) {
+	filter := bson.M{
+		"_id":       current.ID,
+		"status_id": current.StatusID,
+		"version":   versionFilter(current.Version),
+	}
 	update := bson.M{
 		"$set": bson.M{
 			"status_id":  statusID,
@@ -61,9 +132,122 @@ func (r *OrderStatusRepository) UpdateStatusWithEntry(ctx context.Context, id pr
 		"$push": bson.M{
 			"history": entry,
 		},
+		"$inc": bson.M{
+			"version": 1,
+		},
 	}
-	_, err := r.Collection.UpdateByID(ctx, id, update)
-	return err
+
+	var updated model.OrderStatus
+	err := r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		if err := r.claimStatus(sc, statusID); err != nil {
+			return err
+		}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := r.Collection.FindOneAndUpdate(sc, filter, update, opts).Decode(&updated)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			count, countErr := r.Collection.CountDocuments(sc, bson.M{"_id": current.ID})
+			if countErr != nil {
+				return countErr
+			}
+			if count > 0 {
+				return ErrVersionConflict
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		return r.outbox.Insert(sc, model.OutboxEvent{
+			Type:        model.EventStatusChanged,
+			AggregateID: current.ID.Hex(),
+			Payload: model.OrderStatusEventPayload{
+				OrderStatusID:    current.ID.Hex(),
+				OrderID:          current.OrderID,
+				UserID:           current.UserID,
+				StatusID:         statusID.Hex(),
+				Status:           statusName,
+				PreviousStatusID: current.StatusID.Hex(),
+				PreviousStatus:   current.Status,
+				Entry:            &entry,
+			},
+		})
+	})
+	return updated, err
+}
+
+// UpdateShipping replaces the shipping address, pushes the revision to shipping_history and
+// writes an order.shipping_updated event to the outbox in the same transaction.
+// Like UpdateStatusWithEntry, it only applies if the document still has the version and status
+// it had when current was read; otherwise ErrVersionConflict is returned.
+func (r *OrderStatusRepository) UpdateShipping(ctx context.Context, current model.OrderStatus, shipping model.ShippingInfo, revision model.ShippingRevision) (model.OrderStatus, error) {
+	filter := bson.M{
+		"_id":       current.ID,
+		"status_id": current.StatusID,
+		"version":   versionFilter(current.Version),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"shipping":   shipping,
+			"updated_at": time.Now(),
+		},
+		"$push": bson.M{
+			"shipping_history": revision,
+		},
+		"$inc": bson.M{
+			"version": 1,
+		},
+	}
+
+	var updated model.OrderStatus
+	err := r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := r.Collection.FindOneAndUpdate(sc, filter, update, opts).Decode(&updated)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			count, countErr := r.Collection.CountDocuments(sc, bson.M{"_id": current.ID})
+			if countErr != nil {
+				return countErr
+			}
+			if count > 0 {
+				return ErrVersionConflict
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		return r.outbox.Insert(sc, model.OutboxEvent{
+			Type:        model.EventShippingUpdated,
+			AggregateID: current.ID.Hex(),
+			Payload: model.OrderStatusEventPayload{
+				OrderStatusID: current.ID.Hex(),
+				OrderID:       current.OrderID,
+				UserID:        current.UserID,
+				StatusID:      current.StatusID.Hex(),
+				Status:        current.Status,
+				Shipping:      &revision,
+			},
+		})
+	})
+	return updated, err
+}
+
+// CountByStatusID counts the documents currently pointing at a catalog status
+func (r *OrderStatusRepository) CountByStatusID(ctx context.Context, statusID primitive.ObjectID) (int64, error) {
+	return r.Collection.CountDocuments(ctx, bson.M{"status_id": statusID})
+}
+
+// RenameStatus updates the denormalized status name of every document pointing at a catalog status
+func (r *OrderStatusRepository) RenameStatus(ctx context.Context, statusID primitive.ObjectID, statusName string) (int64, error) {
+	res, err := r.Collection.UpdateMany(ctx,
+		bson.M{"status_id": statusID, "status": bson.M{"$ne": statusName}},
+		bson.M{"$set": bson.M{"status": statusName}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
 }
 
 // GetBaseStatuses (returns distinct status names) - retained for compatibility
@@ -79,58 +263,116 @@ func (r *OrderStatusRepository) GetBaseStatuses(ctx context.Context) ([]string,
 	return names, nil
 }
 
-func (r *OrderStatusRepository) FindAll(ctx context.Context) ([]model.OrderStatus, error) {
-	cursor, err := r.Collection.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-	var results []model.OrderStatus
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, err
-	}
-	return results, nil
+// Sortable fields for paginated listings
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+)
+
+// OrderStatusQuery describes a paginated listing. Empty filters are ignored and
+// non-empty ones are combined with AND.
+type OrderStatusQuery struct {
+	UserID    string
+	SellerID  string
+	Party     string // matches the owner or any assigned seller
+	Statuses  []string
+	StatusIDs []primitive.ObjectID
+	From      *time.Time // inclusive, applied to the sort field
+	To        *time.Time // exclusive, applied to the sort field
+	Country   string
+	City      string
+
+	Sort      string // SortByCreatedAt or SortByUpdatedAt
+	Ascending bool
+	Limit     int64
+	Cursor    string
 }
 
-func (r *OrderStatusRepository) FindByUser(ctx context.Context, userID string) ([]model.OrderStatus, error) {
-	cursor, err := r.Collection.Find(ctx, bson.M{"user_id": userID})
-	if err != nil {
-		return nil, err
+// FindPage returns one page of order statuses using keyset pagination on (sort field, _id),
+// plus the cursor for the next page ("" when there are no more results)
+func (r *OrderStatusRepository) FindPage(ctx context.Context, q OrderStatusQuery) ([]model.OrderStatus, string, error) {
+	and := bson.A{}
+	if q.UserID != "" {
+		and = append(and, bson.M{"user_id": q.UserID})
 	}
-	defer cursor.Close(ctx)
-
-	var results []model.OrderStatus
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, err
+	if q.SellerID != "" {
+		and = append(and, bson.M{"seller_ids": q.SellerID})
+	}
+	if q.Party != "" {
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"user_id": q.Party},
+			bson.M{"seller_ids": q.Party},
+		}})
+	}
+	if len(q.Statuses) > 0 {
+		and = append(and, bson.M{"status": bson.M{"$in": q.Statuses}})
+	}
+	if len(q.StatusIDs) > 0 {
+		and = append(and, bson.M{"status_id": bson.M{"$in": q.StatusIDs}})
+	}
+	if q.From != nil || q.To != nil {
+		rng := bson.M{}
+		if q.From != nil {
+			rng["$gte"] = *q.From
+		}
+		if q.To != nil {
+			rng["$lt"] = *q.To
+		}
+		and = append(and, bson.M{q.Sort: rng})
+	}
+	if q.Country != "" {
+		and = append(and, bson.M{"shipping.country": equalFold(q.Country)})
+	}
+	if q.City != "" {
+		and = append(and, bson.M{"shipping.city": equalFold(q.City)})
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, q.Sort, q.Ascending)
+		if err != nil {
+			return nil, "", err
+		}
+		and = append(and, keysetFilter(c, "_id"))
 	}
 
-	return results, nil
-}
-
-func (r *OrderStatusRepository) FindByStatus(ctx context.Context, status string) ([]model.OrderStatus, error) {
-	cursor, err := r.Collection.Find(ctx, bson.M{"status": status})
-	if err != nil {
-		return nil, err
+	filter := bson.M{}
+	if len(and) > 0 {
+		filter["$and"] = and
 	}
-	defer cursor.Close(ctx)
-	var results []model.OrderStatus
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, err
+
+	direction := -1
+	if q.Ascending {
+		direction = 1
 	}
-	return results, nil
-}
+	// se pide un elemento extra para saber si hay otra página
+	opts := options.Find().
+		SetSort(bson.D{{Key: q.Sort, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(q.Limit + 1)
 
-func (r *OrderStatusRepository) FindByStatusID(ctx context.Context, statusID primitive.ObjectID) ([]model.OrderStatus, error) {
-	cursor, err := r.Collection.Find(ctx, bson.M{"status_id": statusID})
+	cursor, err := r.Collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer cursor.Close(ctx)
 
-	var results []model.OrderStatus
+	results := []model.OrderStatus{}
 	if err = cursor.All(ctx, &results); err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	next := ""
+	if int64(len(results)) > q.Limit {
+		results = results[:q.Limit]
+		last := results[len(results)-1]
+		value := last.CreatedAt
+		if q.Sort == SortByUpdatedAt {
+			value = last.UpdatedAt
+		}
+		next = encodeCursor(pageCursor{Sort: q.Sort, Asc: q.Ascending, Value: value, ID: last.ID})
 	}
+	return results, next, nil
+}
 
-	return results, nil
+// equalFold matches a string field case-insensitively
+func equalFold(value string) bson.M {
+	return bson.M{"$regex": "^" + regexp.QuoteMeta(value) + "$", "$options": "i"}
 }
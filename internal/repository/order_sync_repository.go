@@ -0,0 +1,99 @@
+// order_sync_repository.go
+package repository
+
+import (
+	"context"
+	"order-status-service/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrderSyncRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewOrderSyncRepository(db *mongo.Database) *OrderSyncRepository {
+	return &OrderSyncRepository{Collection: db.Collection("order_syncs")}
+}
+
+// Insert stores a pending sync. A sync that already exists for the same outbox event is
+// skipped, so an event can be fanned out again safely.
+func (r *OrderSyncRepository) Insert(ctx context.Context, sync model.OrderSync) error {
+	_, err := r.Collection.InsertOne(ctx, sync)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// FindByStatus lists syncs in the given status, newest first
+func (r *OrderSyncRepository) FindByStatus(ctx context.Context, status model.DeliveryStatus, limit int64) ([]model.OrderSync, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.Collection.Find(ctx, bson.M{"status": status}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []model.OrderSync{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ClaimNext takes the oldest due pending sync and leases it
+func (r *OrderSyncRepository) ClaimNext(ctx context.Context, lease time.Duration) (model.OrderSync, error) {
+	now := time.Now()
+	filter := bson.M{
+		"status":          model.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var res model.OrderSync
+	err := r.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&res)
+	return res, err
+}
+
+// RecordAttempt appends the outcome of an attempt and sets the resulting status
+func (r *OrderSyncRepository) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"next_attempt_at": nextAttemptAt,
+			"updated_at":      time.Now(),
+		},
+		"$push": bson.M{"attempts": attempt},
+		"$inc":  bson.M{"retries": 1},
+	})
+	return err
+}
+
+// Reset schedules a sync to be sent again right away, keeping its previous attempts
+func (r *OrderSyncRepository) Reset(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	res, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"status":          model.DeliveryPending,
+			"retries":         0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// outbox_repository.go
+package repository
+
+import (
+	"context"
+	"order-status-service/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OutboxRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewOutboxRepository(db *mongo.Database) *OutboxRepository {
+	return &OutboxRepository{
+		Collection: db.Collection("outbox_events"),
+	}
+}
+
+// Insert writes a pending event. Pass the session context to make it part of a transaction.
+func (r *OutboxRepository) Insert(ctx context.Context, event model.OutboxEvent) error {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = now
+	}
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = now
+	}
+	event.Status = model.OutboxPending
+	_, err := r.Collection.InsertOne(ctx, event)
+	return err
+}
+
+// ClaimNext takes the oldest due pending event and leases it for the given duration,
+// so that a concurrent relay does not publish it at the same time
+func (r *OutboxRepository) ClaimNext(ctx context.Context, lease time.Duration) (model.OutboxEvent, error) {
+	now := time.Now()
+	filter := bson.M{
+		"status":          model.OutboxPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var res model.OutboxEvent
+	err := r.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&res)
+	return res, err
+}
+
+// MarkPublished flags an event as delivered to the publisher
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set":   bson.M{"status": model.OutboxPublished, "published_at": now},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"last_error": ""},
+	})
+	return err
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (r *OutboxRepository) MarkRetry(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, lastErr string) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"next_attempt_at": nextAttemptAt, "last_error": lastErr},
+		"$inc": bson.M{"attempts": 1},
+	})
+	return err
+}
+
+// MarkFailed gives up on an event after too many attempts
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, lastErr string) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"status": model.OutboxFailed, "last_error": lastErr},
+		"$inc": bson.M{"attempts": 1},
+	})
+	return err
+}
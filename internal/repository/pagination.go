@@ -0,0 +1,56 @@
+// pagination.go
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or does not match the requested sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position after the last item of a page. It is sent to clients as an
+// opaque base64 string and includes the sort it was generated for.
+type pageCursor struct {
+	Sort  string             `json:"s"`
+	Asc   bool               `json:"a"`
+	Value time.Time          `json:"v"`
+	ID    primitive.ObjectID `json:"i"`
+}
+
+func encodeCursor(c pageCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string, sort string, asc bool) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	if c.Sort != sort || c.Asc != asc {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// keysetFilter matches the documents strictly after the cursor in (sort field, id field) order
+func keysetFilter(c pageCursor, idField string) bson.M {
+	op := "$lt"
+	if c.Asc {
+		op = "$gt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{c.Sort: bson.M{op: c.Value}},
+		bson.M{c.Sort: c.Value, idField: bson.M{op: c.ID}},
+	}}
+}
@@ -0,0 +1,182 @@
+// webhook_repository.go
+package repository
+
+import (
+	"context"
+	"order-status-service/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepository struct {
+	Subscriptions *mongo.Collection
+	Deliveries    *mongo.Collection
+}
+
+func NewWebhookRepository(db *mongo.Database) *WebhookRepository {
+	return &WebhookRepository{
+		Subscriptions: db.Collection("webhook_subscriptions"),
+		Deliveries:    db.Collection("webhook_deliveries"),
+	}
+}
+
+// InsertSubscription registers a new subscription
+func (r *WebhookRepository) InsertSubscription(ctx context.Context, sub model.WebhookSubscription) error {
+	_, err := r.Subscriptions.InsertOne(ctx, sub)
+	return err
+}
+
+// FindSubscriptions lists every subscription
+func (r *WebhookRepository) FindSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	cursor, err := r.Subscriptions.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []model.WebhookSubscription{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// FindSubscriptionByID retrieves a subscription by its ObjectID
+func (r *WebhookRepository) FindSubscriptionByID(ctx context.Context, id primitive.ObjectID) (model.WebhookSubscription, error) {
+	var res model.WebhookSubscription
+	err := r.Subscriptions.FindOne(ctx, bson.M{"_id": id}).Decode(&res)
+	return res, err
+}
+
+// FindActiveFor returns the active subscriptions to an event that are interested in a catalog status
+func (r *WebhookRepository) FindActiveFor(ctx context.Context, statusID primitive.ObjectID, event string) ([]model.WebhookSubscription, error) {
+	filter := bson.M{
+		"active": true,
+		"events": event,
+		"$or": bson.A{
+			bson.M{"status_ids": statusID},
+			bson.M{"status_ids": bson.M{"$size": 0}},
+			bson.M{"status_ids": nil},
+		},
+	}
+	cursor, err := r.Subscriptions.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []model.WebhookSubscription
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DeleteSubscription removes a subscription
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.Subscriptions.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// InsertDeliveries stores pending deliveries. Deliveries that already exist for the same
+// event and subscription are skipped, so an event can be fanned out again safely.
+func (r *WebhookRepository) InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	docs := make([]interface{}, len(deliveries))
+	for i, d := range deliveries {
+		docs[i] = d
+	}
+	_, err := r.Deliveries.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if onlyDuplicateKeys(err) {
+		return nil
+	}
+	return err
+}
+
+// FindDeliveryByID retrieves a delivery by its ObjectID
+func (r *WebhookRepository) FindDeliveryByID(ctx context.Context, id primitive.ObjectID) (model.WebhookDelivery, error) {
+	var res model.WebhookDelivery
+	err := r.Deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&res)
+	return res, err
+}
+
+// FindDeliveries lists the deliveries of a subscription, newest first, optionally filtered by status
+func (r *WebhookRepository) FindDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status model.DeliveryStatus, limit int64) ([]model.WebhookDelivery, error) {
+	filter := bson.M{"subscription_id": subscriptionID}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.Deliveries.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []model.WebhookDelivery{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ClaimNextDelivery takes the oldest due pending delivery and leases it
+func (r *WebhookRepository) ClaimNextDelivery(ctx context.Context, lease time.Duration) (model.WebhookDelivery, error) {
+	now := time.Now()
+	filter := bson.M{
+		"status":          model.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var res model.WebhookDelivery
+	err := r.Deliveries.FindOneAndUpdate(ctx, filter, update, opts).Decode(&res)
+	return res, err
+}
+
+// RecordAttempt appends the outcome of an attempt and sets the resulting status
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time) error {
+	_, err := r.Deliveries.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"next_attempt_at": nextAttemptAt,
+			"updated_at":      time.Now(),
+		},
+		"$push": bson.M{"attempts": attempt},
+		"$inc":  bson.M{"retries": 1},
+	})
+	return err
+}
+
+// ResetDelivery schedules a delivery to be sent again right away, keeping its previous attempts
+func (r *WebhookRepository) ResetDelivery(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	res, err := r.Deliveries.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"status":          model.DeliveryPending,
+			"retries":         0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -2,15 +2,42 @@
 package service
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
+	"order-status-service/internal/config"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Modos de validación de tokens
+const (
+	AuthModeRemote = "remote"
+	AuthModeJWKS   = "jwks"
 )
 
+// Se devuelve cuando el token fue rechazado (a diferencia de una falla al validarlo)
+var ErrInvalidToken = errors.New("invalid token")
+
 type AuthService struct {
 	authURL string
+	client  *http.Client
+	mode    string
+	// solo en modo jwks
+	jwks           *JWKSVerifier
+	remoteFallback bool
+
+	// caché de validaciones (nil si está desactivada)
+	cache       *tokenCache
+	cacheTTL    time.Duration
+	negativeTTL time.Duration
+	// una sola validación en curso por token
+	inflight singleflight.Group
 }
 
 type AuthUser struct {
@@ -19,41 +46,139 @@ type AuthUser struct {
 	Permissions []string `json:"permissions"`
 	Login       string   `json:"login"`
 	Enabled     bool     `json:"enabled"`
+
+	// vencimiento del token, si se conoce (modo jwks)
+	expiresAt time.Time
 }
 
 // Crea un nuevo AuthService
-func NewAuthService() *AuthService {
-	return &AuthService{
-		authURL: os.Getenv("AUTH_SERVICE_URL"),
+func NewAuthService(cfg config.AuthConfig) *AuthService {
+	a := &AuthService{
+		authURL:        cfg.URL,
+		client:         &http.Client{Timeout: cfg.Timeout},
+		mode:           AuthModeRemote,
+		remoteFallback: cfg.RemoteFallback,
+		cacheTTL:       cfg.CacheTTL,
+		negativeTTL:    cfg.CacheNegativeTTL,
+	}
+	if cfg.Mode == AuthModeJWKS {
+		a.mode = AuthModeJWKS
+		a.jwks = NewJWKSVerifier(cfg.JWKSURL, cfg.Audience, cfg.Issuer, cfg.JWKSRefresh, cfg.Timeout)
+	}
+	if cfg.CacheSize > 0 {
+		a.cache = newTokenCache(cfg.CacheSize)
+	}
+	return a
+}
+
+// Run mantiene actualizado el JWKS en modo jwks; en modo remoto no hace nada
+func (a *AuthService) Run(ctx context.Context) {
+	if a.jwks != nil {
+		a.jwks.Run(ctx)
+	}
+}
+
+// ValidateToken valida el token usando la caché: los tokens válidos se recuerdan por CacheTTL
+// (nunca más allá de su exp) y los rechazados por CacheNegativeTTL. Las fallas transitorias
+// (servicio de autenticación caído) no se cachean.
+func (a *AuthService) ValidateToken(ctx context.Context, token string) (*AuthUser, error) {
+	if a.cache == nil {
+		return a.validate(ctx, token)
+	}
+
+	key := tokenKey(token)
+	if entry, ok := a.cache.get(key); ok {
+		return entry.user, entry.err
+	}
+
+	// validaciones concurrentes del mismo token comparten una sola llamada, que no se cancela
+	// si se desconecta el cliente que la inició (los demás siguen esperando su resultado)
+	flight := a.inflight.DoChan(hex.EncodeToString(key[:]), func() (interface{}, error) {
+		user, err := a.validate(context.WithoutCancel(ctx), token)
+		switch {
+		case err == nil:
+			ttl := a.cacheTTL
+			if !user.expiresAt.IsZero() {
+				ttl = min(ttl, time.Until(user.expiresAt))
+			}
+			a.cache.add(key, user, nil, ttl)
+		case errors.Is(err, ErrInvalidToken):
+			a.cache.add(key, nil, err, a.negativeTTL)
+		}
+		return user, err
+	})
+
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-flight:
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	return res.Val.(*AuthUser), nil
+}
+
+// FlushCache vacía la caché de tokens y devuelve cuántas entradas se descartaron
+func (a *AuthService) FlushCache() int {
+	if a.cache == nil {
+		return 0
+	}
+	return a.cache.flush()
+}
+
+// CacheStats devuelve los contadores de la caché de tokens
+func (a *AuthService) CacheStats() TokenCacheStats {
+	if a.cache == nil {
+		return TokenCacheStats{}
 	}
+	return a.cache.stats()
 }
 
-func (a *AuthService) IsAdmin(user *AuthUser) bool {
-	for _, perm := range user.Permissions {
-		if perm == "admin" {
-			return true
+// Valida el token: localmente contra el JWKS (modo jwks) o llamando al microservicio de autenticación
+func (a *AuthService) validate(ctx context.Context, token string) (*AuthUser, error) {
+	if a.mode != AuthModeJWKS {
+		return a.introspect(ctx, token)
+	}
+
+	user, err := a.jwks.Verify(ctx, token)
+	if errors.Is(err, ErrJWKSUnavailable) {
+		if a.remoteFallback {
+			log.Printf("⚠️ %v, falling back to remote token validation", err)
+			return a.introspect(ctx, token)
 		}
+		return nil, err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// se cortó la espera del JWKS: el token no fue rechazado (ni se cachea como tal)
+		return nil, err
 	}
-	return false
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return user, nil
 }
 
-// Valida el token JWT llamando al microservicio de autenticación
-func (a *AuthService) ValidateToken(token string) (*AuthUser, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/users/current", a.authURL), nil)
+// Valida el token llamando a GET /users/current del microservicio de autenticación
+func (a *AuthService) introspect(ctx context.Context, token string) (*AuthUser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/users/current", a.authURL), nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := a.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return nil, ErrInvalidToken
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("invalid token")
+		return nil, fmt.Errorf("auth service returned status %d", resp.StatusCode)
 	}
 
 	var user AuthUser
@@ -62,11 +187,8 @@ func (a *AuthService) ValidateToken(token string) (*AuthUser, error) {
 	}
 
 	if !user.Enabled {
-		return nil, errors.New("user disabled")
+		return nil, fmt.Errorf("%w: user disabled", ErrInvalidToken)
 	}
 
-	fmt.Println("AUTH_SERVICE_URL =", a.authURL)
-	fmt.Println("Calling:", fmt.Sprintf("%s/users/current", a.authURL))
-
 	return &user, nil
 }
@@ -4,41 +4,423 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"order-status-service/internal/config"
+	"order-status-service/internal/dto"
+	"order-status-service/internal/mapper"
 	"order-status-service/internal/model"
+	"order-status-service/internal/policy"
 	"order-status-service/internal/repository"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Roles que pueden figurar en una transición del catálogo
+var transitionRoles = map[string]bool{
+	string(policy.RoleAdmin):  true,
+	string(policy.RoleSeller): true,
+	string(policy.RoleClient): true,
+}
+
+// Se devuelve al intentar eliminar un estado que todavía referencian órdenes
+var ErrStatusInUse = errors.New("status is still used by orders")
+
 type CatalogAdminService struct {
-	repo *repository.CatalogRepository
+	repo      *repository.CatalogRepository
+	orderRepo *repository.OrderStatusRepository
+	// contexto raíz del proceso para el trabajo en segundo plano (se cancela al apagar el servicio)
+	background context.Context
+	timeouts   config.TimeoutsConfig
+	// serializa la propagación de renombres para que una más vieja no pise a una más nueva
+	renameMu sync.Mutex
 }
 
-func NewCatalogAdminService(repo *repository.CatalogRepository) *CatalogAdminService {
-	return &CatalogAdminService{repo: repo}
+func NewCatalogAdminService(ctx context.Context, repo *repository.CatalogRepository, orderRepo *repository.OrderStatusRepository, timeouts config.TimeoutsConfig) *CatalogAdminService {
+	return &CatalogAdminService{repo: repo, orderRepo: orderRepo, background: ctx, timeouts: timeouts}
 }
 
 // Crea un nuevo estado en el catálogo base (solo admins)
-func (s *CatalogAdminService) CreateStatus(name string) error {
-	exists, err := s.repo.ExistsByName(name)
+func (s *CatalogAdminService) CreateStatus(ctx context.Context, req dto.CreateCatalogStatusRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	exists, err := s.repo.ExistsByName(ctx, req.Name)
 	if err != nil {
 		return err
 	}
 	if exists {
-		return errors.New("status already exists in catalog")
+		return NewError(ErrConflict, "catalog.status_exists")
+	}
+
+	category := model.StatusCategory(req.Category)
+	if !category.IsValid() {
+		return NewError(ErrInvalidInput, "catalog.invalid_category", req.Category)
+	}
+
+	code := req.Code
+	if code == "" {
+		code = codeFromName(req.Name)
+	}
+	if !statusCodePattern.MatchString(code) {
+		return NewError(ErrInvalidInput, "catalog.invalid_code", code)
+	}
+	exists, err = s.repo.ExistsByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return NewError(ErrConflict, "catalog.code_exists", code)
+	}
+	if req.IsTerminal && len(req.Transitions) > 0 {
+		return NewError(ErrInvalidInput, "catalog.terminal_with_transitions")
+	}
+	if req.IsInitial && req.IsTerminal {
+		return NewError(ErrInvalidInput, "catalog.initial_terminal")
+	}
+
+	transitions, err := s.validateTransitions(ctx, primitive.NilObjectID, req.Transitions)
+	if err != nil {
+		return err
 	}
 
 	status := model.StatusCatalog{
-		Name:      name,
-		CreatedAt: time.Now(),
+		ID:          primitive.NewObjectID(),
+		Code:        code,
+		Name:        req.Name,
+		Labels:      normalizeLabels(req.Labels),
+		IsInitial:   req.IsInitial,
+		IsTerminal:  req.IsTerminal,
+		Category:    category,
+		Transitions: transitions,
+		CreatedAt:   time.Now(),
+	}
+	// Si es el nuevo estado inicial, el anterior se desmarca en la misma transacción
+	if err := s.repo.InsertOne(ctx, status); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return NewError(ErrConflict, "catalog.status_exists")
+		}
+		return err
+	}
+	return nil
+}
+
+// Reemplaza las transiciones salientes de un estado existente
+func (s *CatalogAdminService) SetTransitions(ctx context.Context, id string, req dto.UpdateTransitionsRequest) (*model.StatusCatalog, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, NewError(ErrInvalidInput, "status.invalid_id")
+	}
+
+	current, err := s.repo.FindByID(ctx, objID)
+	if err != nil {
+		return nil, notFound(err, "status.not_found")
+	}
+	if current.IsTerminal && len(req.Transitions) > 0 {
+		return nil, NewError(ErrInvalidInput, "catalog.terminal_with_transitions")
+	}
+
+	transitions, err := s.validateTransitions(ctx, objID, req.Transitions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTransitions(ctx, objID, transitions); err != nil {
+		return nil, notFound(err, "status.not_found")
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// Modifica nombre y/o atributos de un estado. Un cambio de nombre se propaga
+// en segundo plano al campo desnormalizado "status" de las órdenes.
+func (s *CatalogAdminService) UpdateStatus(ctx context.Context, id string, req dto.UpdateCatalogStatusRequest) (*model.StatusCatalog, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, NewError(ErrInvalidInput, "status.invalid_id")
+	}
+	current, err := s.repo.FindByID(ctx, objID)
+	if err != nil {
+		return nil, notFound(err, "status.not_found")
+	}
+
+	fields := bson.M{}
+	renamed := false
+	if req.Name != nil && *req.Name != current.Name {
+		if *req.Name == "" {
+			return nil, NewError(ErrInvalidInput, "catalog.name_required")
+		}
+		exists, err := s.repo.ExistsByName(ctx, *req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, NewError(ErrConflict, "catalog.status_exists")
+		}
+		fields["name"] = *req.Name
+		renamed = true
+	}
+	if req.Labels != nil {
+		fields["labels"] = normalizeLabels(req.Labels)
+	}
+	if req.Category != nil {
+		category := model.StatusCategory(*req.Category)
+		if !category.IsValid() {
+			return nil, NewError(ErrInvalidInput, "catalog.invalid_category", *req.Category)
+		}
+		fields["category"] = category
+	}
+	if req.IsTerminal != nil {
+		if *req.IsTerminal && len(current.Transitions) > 0 {
+			return nil, NewError(ErrInvalidInput, "catalog.terminal_with_transitions")
+		}
+		fields["is_terminal"] = *req.IsTerminal
+	}
+	if req.IsInitial != nil {
+		if *req.IsInitial && current.Archived {
+			return nil, NewError(ErrStatusArchived, "catalog.archived_initial")
+		}
+		// Siempre tiene que haber un estado inicial: deja de serlo solo al marcar otro como inicial
+		if !*req.IsInitial && current.IsInitial {
+			return nil, NewError(ErrConflict, "catalog.unset_initial")
+		}
+		fields["is_initial"] = *req.IsInitial
+	}
+	isInitial, isTerminal := current.IsInitial, current.IsTerminal
+	if req.IsInitial != nil {
+		isInitial = *req.IsInitial
+	}
+	if req.IsTerminal != nil {
+		isTerminal = *req.IsTerminal
+	}
+	if isInitial && isTerminal {
+		return nil, NewError(ErrInvalidInput, "catalog.initial_terminal")
+	}
+	if len(fields) == 0 {
+		return &current, nil
+	}
+	fields["updated_at"] = time.Now()
+
+	// Solo puede haber un estado inicial: el anterior se desmarca en la misma transacción
+	update := s.repo.Update
+	if req.IsInitial != nil && *req.IsInitial {
+		update = s.repo.UpdateInitial
+	}
+	if err := update(ctx, objID, fields); errors.Is(err, repository.ErrDuplicateKey) {
+		return nil, NewError(ErrConflict, "catalog.status_exists")
+	} else if err != nil {
+		return nil, notFound(err, "status.not_found")
+	}
+	if renamed {
+		go s.propagateRename(objID)
 	}
-	return s.repo.InsertOne(context.Background(), status)
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// Actualiza el nombre desnormalizado en las órdenes para que FindByStatus siga funcionando.
+// Las propagaciones corren de a una y cada una relee el nombre vigente del catálogo, así que
+// dos renombres seguidos nunca dejan a las órdenes con el nombre anterior.
+func (s *CatalogAdminService) propagateRename(statusID primitive.ObjectID) {
+	s.renameMu.Lock()
+	defer s.renameMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(s.background, s.timeouts.Bulk)
+	defer cancel()
+
+	status, err := s.repo.FindByID(ctx, statusID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️ Error al leer el estado %s para propagar su nombre: %v", statusID.Hex(), err)
+		return
+	}
+	name := status.Name
+
+	updated, err := s.orderRepo.RenameStatus(ctx, statusID, name)
+	if err != nil {
+		log.Printf("⚠️ Error al propagar el nuevo nombre '%s' del estado %s: %v", name, statusID.Hex(), err)
+		return
+	}
+	log.Printf("✅ Nombre '%s' propagado a %d órdenes", name, updated)
+}
+
+// Archiva un estado: deja de poder asignarse, pero las órdenes que ya lo tienen lo conservan
+func (s *CatalogAdminService) ArchiveStatus(ctx context.Context, id string) (*model.StatusCatalog, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, NewError(ErrInvalidInput, "status.invalid_id")
+	}
+	current, err := s.repo.FindByID(ctx, objID)
+	if err != nil {
+		return nil, notFound(err, "status.not_found")
+	}
+	if current.IsInitial {
+		return nil, NewError(ErrConflict, "catalog.archive_initial")
+	}
+	if current.Archived {
+		return &current, nil
+	}
+
+	now := time.Now()
+	if err := s.repo.Update(ctx, objID, bson.M{"archived": true, "archived_at": now, "updated_at": now}); err != nil {
+		return nil, notFound(err, "status.not_found")
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// Elimina un estado del catálogo, solo si ninguna orden lo referencia. Antes de contar las órdenes
+// el estado se archiva, para que ningún cambio de estado nuevo pueda asignarlo entre el conteo
+// y el borrado; si resulta estar en uso, se restaura como estaba. Un cambio de estado que ya
+// había validado el destino antes del archivado también lo marca dentro de su transacción (ver
+// OrderStatusRepository.UpdateStatusWithEntry): o falla, o el archivado espera a que confirme
+// y el conteo ya lo incluye.
+func (s *CatalogAdminService) DeleteStatus(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return NewError(ErrInvalidInput, "status.invalid_id")
+	}
+	current, err := s.repo.FindByID(ctx, objID)
+	if err != nil {
+		return notFound(err, "status.not_found")
+	}
+	if current.IsInitial {
+		return NewError(ErrConflict, "catalog.delete_initial")
+	}
+
+	if !current.Archived {
+		now := time.Now()
+		if err := s.repo.Update(ctx, objID, bson.M{"archived": true, "archived_at": now, "updated_at": now}); err != nil {
+			return notFound(err, "status.not_found")
+		}
+	}
+
+	inUse, err := s.orderRepo.CountByStatusID(ctx, objID)
+	if err == nil && inUse > 0 {
+		err = NewError(ErrStatusInUse, "catalog.status_in_use", inUse)
+	}
+	if err != nil {
+		if !current.Archived {
+			if restoreErr := s.repo.Unarchive(ctx, objID); restoreErr != nil {
+				log.Printf("⚠️ Error al restaurar el estado %s: %v", objID.Hex(), restoreErr)
+			}
+		}
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, objID); err != nil {
+		return notFound(err, "status.not_found")
+	}
+	return s.repo.PullTransitionsTo(ctx, objID)
+}
+
+var statusCodePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Deriva un código estable a partir del nombre ("En tránsito" -> "en_transito")
+func codeFromName(name string) string {
+	name = strings.NewReplacer(
+		"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+		"ã", "a", "õ", "o", "â", "a", "ê", "e", "ô", "o", "ç", "c",
+	).Replace(strings.ToLower(strings.TrimSpace(name)))
+
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			b.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// Normaliza las claves de idioma de las etiquetas ("EN" -> "en") y descarta las vacías
+func normalizeLabels(labels map[string]string) map[string]string {
+	res := make(map[string]string, len(labels))
+	for locale, label := range labels {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if locale == "" || strings.TrimSpace(label) == "" {
+			continue
+		}
+		res[locale] = label
+	}
+	return res
+}
+
+// Valida que los destinos existan en el catálogo y que los roles sean conocidos
+func (s *CatalogAdminService) validateTransitions(ctx context.Context, fromID primitive.ObjectID, dtos []dto.TransitionDTO) ([]model.StatusTransition, error) {
+	for _, t := range dtos {
+		if !primitive.IsValidObjectID(t.ToID) {
+			return nil, NewError(ErrInvalidInput, "catalog.invalid_transition_target", t.ToID)
+		}
+	}
+	transitions, err := mapper.ToTransitionEntities(dtos)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[primitive.ObjectID]bool, len(transitions))
+	for _, t := range transitions {
+		if t.ToID == fromID {
+			return nil, NewError(ErrInvalidInput, "catalog.self_transition")
+		}
+		if seen[t.ToID] {
+			return nil, NewError(ErrInvalidInput, "catalog.duplicated_transition", t.ToID.Hex())
+		}
+		seen[t.ToID] = true
+
+		target, err := s.repo.FindByID(ctx, t.ToID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, NewError(ErrInvalidInput, "catalog.transition_target_not_found", t.ToID.Hex())
+		}
+		if err != nil {
+			return nil, err
+		}
+		if target.Archived {
+			return nil, NewError(ErrStatusArchived, "catalog.transition_target_archived", target.Name)
+		}
+		for _, role := range t.Roles {
+			if !transitionRoles[role] {
+				return nil, NewError(ErrInvalidInput, "catalog.unknown_role", role)
+			}
+		}
+	}
+	return transitions, nil
 }
 
 // Devuelve todos los estados del catálogo base
-func (s *CatalogAdminService) GetAll() ([]model.StatusCatalog, error) {
-	return s.repo.GetAll()
+func (s *CatalogAdminService) GetAll(ctx context.Context) ([]model.StatusCatalog, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.repo.GetAll(ctx)
 }
 
-func (s *CatalogAdminService) GetByID(id string) (*model.StatusCatalog, error) {
-	return s.repo.GetByID(id)
+func (s *CatalogAdminService) GetByID(ctx context.Context, id string) (*model.StatusCatalog, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, NewError(ErrInvalidInput, "status.invalid_id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	status, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "status.not_found")
+	}
+	return status, nil
 }
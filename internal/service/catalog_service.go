@@ -2,42 +2,129 @@
 package service
 
 import (
+	"context"
 	"log"
 	"time"
 
+	"order-status-service/internal/config"
 	"order-status-service/internal/model"
 	"order-status-service/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type CatalogService struct {
-	Repo *repository.CatalogRepository
+	Repo     *repository.CatalogRepository
+	timeouts config.TimeoutsConfig
+}
+
+func NewCatalogService(repo *repository.CatalogRepository, timeouts config.TimeoutsConfig) *CatalogService {
+	return &CatalogService{Repo: repo, timeouts: timeouts}
+}
+
+// Estados base en el orden en que se crean, con sus atributos
+var defaultStatuses = []struct {
+	Code     string
+	Name     string
+	Labels   map[string]string
+	Category model.StatusCategory
+	Initial  bool
+	Terminal bool
+}{
+	{Code: "pending", Name: "Pendiente", Category: model.CategoryPending, Initial: true,
+		Labels: map[string]string{"es": "Pendiente", "en": "Pending", "pt": "Pendente"}},
+	{Code: "in_preparation", Name: "En preparación", Category: model.CategoryInProgress,
+		Labels: map[string]string{"es": "En preparación", "en": "In preparation", "pt": "Em preparação"}},
+	{Code: "shipped", Name: "Enviado", Category: model.CategoryShipped,
+		Labels: map[string]string{"es": "Enviado", "en": "Shipped", "pt": "Enviado"}},
+	{Code: "delivered", Name: "Entregado", Category: model.CategoryDelivered, Terminal: true,
+		Labels: map[string]string{"es": "Entregado", "en": "Delivered", "pt": "Entregue"}},
+	{Code: "cancelled", Name: "Cancelado", Category: model.CategoryCancelled, Terminal: true,
+		Labels: map[string]string{"es": "Cancelado", "en": "Cancelled", "pt": "Cancelado"}},
+	{Code: "rejected", Name: "Rechazado", Category: model.CategoryRejected, Terminal: true,
+		Labels: map[string]string{"es": "Rechazado", "en": "Rejected", "pt": "Rejeitado"}},
+}
+
+// Grafo de transiciones por defecto entre los estados base (origen -> destino -> roles).
+// Los estados sin transiciones salientes son terminales.
+var defaultTransitionGraph = map[string][]struct {
+	To    string
+	Roles []string
+}{
+	"Pendiente": {
+		{To: "En preparación", Roles: []string{"admin", "seller"}},
+		{To: "Enviado", Roles: []string{"admin", "seller"}},
+		{To: "Cancelado", Roles: []string{"client"}},
+		{To: "Rechazado", Roles: []string{"admin", "seller"}},
+	},
+	"En preparación": {
+		{To: "Enviado", Roles: []string{"admin", "seller"}},
+		{To: "Cancelado", Roles: []string{"client"}},
+		{To: "Rechazado", Roles: []string{"admin", "seller"}},
+	},
+	"Enviado": {
+		{To: "Entregado", Roles: []string{"admin", "seller"}},
+	},
+}
+
+// Arma las transiciones por defecto de un estado base a partir de los ids ya conocidos
+func defaultTransitions(name string, ids map[string]primitive.ObjectID) []model.StatusTransition {
+	transitions := []model.StatusTransition{}
+	for _, edge := range defaultTransitionGraph[name] {
+		toID, ok := ids[edge.To]
+		if !ok {
+			continue
+		}
+		transitions = append(transitions, model.StatusTransition{ToID: toID, Roles: edge.Roles})
+	}
+	return transitions
 }
 
-func NewCatalogService(repo *repository.CatalogRepository) *CatalogService {
-	return &CatalogService{Repo: repo}
+// Arma el catálogo por defecto con ids nuevos: los estados base y el grafo de transiciones entre ellos
+func defaultCatalog() []model.StatusCatalog {
+	ids := make(map[string]primitive.ObjectID, len(defaultStatuses))
+	for _, st := range defaultStatuses {
+		ids[st.Name] = primitive.NewObjectID()
+	}
+
+	catalog := make([]model.StatusCatalog, 0, len(defaultStatuses))
+	for _, st := range defaultStatuses {
+		catalog = append(catalog, model.StatusCatalog{
+			ID:          ids[st.Name],
+			Code:        st.Code,
+			Name:        st.Name,
+			Labels:      st.Labels,
+			IsInitial:   st.Initial,
+			IsTerminal:  st.Terminal,
+			Category:    st.Category,
+			Transitions: defaultTransitions(st.Name, ids),
+			CreatedAt:   time.Now(),
+		})
+	}
+	return catalog
 }
 
 // Se ejecuta automáticamente al iniciar el microservicio
-func (s *CatalogService) SeedDefaultStatuses() error {
-	count, err := s.Repo.Count()
+func (s *CatalogService) SeedDefaultStatuses(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	count, err := s.Repo.Count(ctx)
 	if err != nil {
 		return err
 	}
 	if count > 0 {
 		log.Println("✅ Estados base ya existen, no se vuelven a crear")
-		return nil
+		return s.backfillDefaults(ctx)
 	}
 
-	defaults := []interface{}{
-		model.StatusCatalog{Name: "Pendiente", CreatedAt: time.Now()},
-		model.StatusCatalog{Name: "En preparación", CreatedAt: time.Now()},
-		model.StatusCatalog{Name: "Enviado", CreatedAt: time.Now()},
-		model.StatusCatalog{Name: "Entregado", CreatedAt: time.Now()},
-		model.StatusCatalog{Name: "Cancelado", CreatedAt: time.Now()},
-		model.StatusCatalog{Name: "Rechazado", CreatedAt: time.Now()},
+	defaults := make([]interface{}, 0, len(defaultStatuses))
+	for _, st := range defaultCatalog() {
+		defaults = append(defaults, st)
 	}
 
-	if err := s.Repo.InsertMany(defaults); err != nil {
+	if err := s.Repo.InsertMany(ctx, defaults); err != nil {
 		return err
 	}
 
@@ -45,6 +132,41 @@ func (s *CatalogService) SeedDefaultStatuses() error {
 	return nil
 }
 
-func (s *CatalogService) GetAll() ([]model.StatusCatalog, error) {
-	return s.Repo.GetAll()
+// Completa atributos y grafo en catálogos creados antes de que existieran.
+// Es el único lugar donde se reconocen los estados base por su nombre.
+func (s *CatalogService) backfillDefaults(ctx context.Context) error {
+	statuses, err := s.Repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]primitive.ObjectID, len(statuses))
+	for _, st := range statuses {
+		ids[st.Name] = st.ID
+	}
+
+	for _, st := range defaultStatuses {
+		id, ok := ids[st.Name]
+		if !ok {
+			continue
+		}
+		defaults := bson.M{
+			"code":        st.Code,
+			"labels":      st.Labels,
+			"is_initial":  st.Initial,
+			"is_terminal": st.Terminal,
+			"category":    st.Category,
+			"transitions": defaultTransitions(st.Name, ids),
+		}
+		if err := s.Repo.SetDefaultsIfMissing(ctx, id, defaults); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CatalogService) GetAll(ctx context.Context) ([]model.StatusCatalog, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.Repo.GetAll(ctx)
 }
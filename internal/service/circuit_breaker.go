@@ -0,0 +1,95 @@
+// circuit_breaker.go
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Se devuelve cuando el circuito está abierto y la llamada no se intenta
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// Estados del circuito
+const (
+	circuitClosed   = "closed"    // las llamadas pasan
+	circuitOpen     = "open"      // las llamadas se rechazan sin intentarlas
+	circuitHalfOpen = "half_open" // pasa una sola llamada de prueba
+)
+
+// circuitBreaker corta las llamadas a una dependencia que viene fallando: tras threshold
+// fallas seguidas se abre durante cooldown; luego deja pasar una llamada de prueba que,
+// según su resultado, lo cierra o lo vuelve a abrir.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openUntil time.Time
+	probing   bool // hay una llamada de prueba en curso (half_open)
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: circuitClosed}
+}
+
+// allow indica si la llamada puede intentarse. Si devuelve nil, el llamador debe
+// informar el resultado con success, failure o cancel.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Now().Before(b.openUntil) {
+			return errCircuitOpen
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return nil
+	case circuitHalfOpen:
+		if b.probing {
+			return errCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = circuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure registra una falla y devuelve true si con ella se abrió el circuito
+func (b *circuitBreaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		opened := b.state != circuitOpen
+		b.state = circuitOpen
+		b.openUntil = time.Now().Add(b.cooldown)
+		return opened
+	}
+	return false
+}
+
+// cancel libera la llamada de prueba sin contarla como éxito ni como falla
+// (p. ej. porque el cliente canceló la petición)
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
@@ -0,0 +1,77 @@
+// circuit_breaker_test.go
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(3, time.Minute)
+
+	// un éxito reinicia la cuenta de fallas seguidas
+	b.failure()
+	b.failure()
+	b.success()
+	for i := 1; i <= 2; i++ {
+		if b.failure() {
+			t.Fatalf("failure() #%d opened the circuit, want it to open on the 3rd consecutive failure", i)
+		}
+		if err := b.allow(); err != nil {
+			t.Fatalf("allow() after %d failures = %v, want nil", i, err)
+		}
+	}
+	if !b.failure() {
+		t.Fatalf("failure() #3 = false, want the circuit to open")
+	}
+	if err := b.allow(); err != errCircuitOpen {
+		t.Errorf("allow() with the circuit open = %v, want errCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	open := func() *circuitBreaker {
+		b := newCircuitBreaker(1, cooldown)
+		b.failure()
+		time.Sleep(cooldown)
+		return b
+	}
+
+	t.Run("single probe", func(t *testing.T) {
+		b := open()
+		if err := b.allow(); err != nil {
+			t.Fatalf("allow() after the cooldown = %v, want the probe to pass", err)
+		}
+		if err := b.allow(); err != errCircuitOpen {
+			t.Fatalf("allow() during the probe = %v, want errCircuitOpen", err)
+		}
+		// una prueba cancelada no decide nada: deja pasar otra
+		b.cancel()
+		if err := b.allow(); err != nil {
+			t.Fatalf("allow() after a canceled probe = %v, want a new probe", err)
+		}
+	})
+
+	t.Run("probe success closes", func(t *testing.T) {
+		b := open()
+		b.allow()
+		b.success()
+		for i := 0; i < 2; i++ {
+			if err := b.allow(); err != nil {
+				t.Fatalf("allow() #%d after a successful probe = %v, want nil", i+1, err)
+			}
+		}
+	})
+
+	t.Run("probe failure reopens", func(t *testing.T) {
+		b := open()
+		b.allow()
+		if !b.failure() {
+			t.Fatalf("failure() of the probe = false, want the circuit to reopen")
+		}
+		if err := b.allow(); err != errCircuitOpen {
+			t.Errorf("allow() after a failed probe = %v, want errCircuitOpen", err)
+		}
+	})
+}
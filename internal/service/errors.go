@@ -0,0 +1,73 @@
+// errors.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"order-status-service/internal/i18n"
+	"order-status-service/internal/policy"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Clases de errores de negocio. Los servicios devuelven errores que las envuelven (errors.Is)
+// y el middleware de errores las traduce a un código HTTP y a un código de error estable.
+var (
+	// El recurso pedido no existe
+	ErrNotFound = errors.New("not found")
+	// Datos de entrada mal formados o que no cumplen las reglas de validación
+	ErrInvalidInput = errors.New("invalid input")
+	// El cambio choca con el estado actual del recurso (p. ej. un nombre ya usado)
+	ErrConflict = errors.New("conflict")
+	// La orden está en un estado terminal y ya no admite cambios
+	ErrTerminalState = errors.New("order status is terminal")
+	// El grafo de transiciones del catálogo no permite el cambio pedido
+	ErrTransitionNotAllowed = errors.New("transition not allowed")
+	// El rol del actor no puede aplicar la transición, aunque exista en el catálogo
+	ErrForbiddenTransition = fmt.Errorf("%w: transition not allowed for this role", policy.ErrForbidden)
+	// El estado del catálogo está archivado y ya no puede asignarse
+	ErrStatusArchived = errors.New("status is archived")
+	// La orden ya se despachó y su dirección de envío no puede corregirse
+	ErrShippingLocked = errors.New("shipping address is locked")
+	// La petición no trae credenciales
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// Al catálogo le falta un estado que debería existir (p. ej. el estado inicial)
+	ErrCatalogInconsistent = errors.New("status catalog is inconsistent")
+	// Una dependencia externa (p. ej. el servicio de autenticación) no está disponible
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// Error es un error de negocio: la clase (ErrNotFound, ErrInvalidInput...) decide el código HTTP
+// y la clave del catálogo de mensajes (i18n) el texto que recibe el cliente, en su idioma
+type Error struct {
+	kind error
+	key  string
+	args []interface{}
+}
+
+// NewError crea un error de la clase indicada con la clave de su mensaje y los argumentos del texto
+func NewError(kind error, key string, args ...interface{}) error {
+	return &Error{kind: kind, key: key, args: args}
+}
+
+// Error devuelve el mensaje en inglés, para logs
+func (e *Error) Error() string {
+	return i18n.Message(i18n.LogLocale, e.key, e.args...)
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// Message devuelve el mensaje para el cliente en el idioma indicado
+func (e *Error) Message(locale string) string {
+	return i18n.Message(locale, e.key, e.args...)
+}
+
+// Traduce mongo.ErrNoDocuments a ErrNotFound con el mensaje indicado; el resto de los errores no cambia
+func notFound(err error, key string, args ...interface{}) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return NewError(ErrNotFound, key, args...)
+	}
+	return err
+}
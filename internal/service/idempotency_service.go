@@ -0,0 +1,99 @@
+// idempotency_service.go
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"order-status-service/internal/config"
+	"order-status-service/internal/model"
+	"order-status-service/internal/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// La clave ya se usó con otro cuerpo, método o ruta
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used with a different request")
+	// La primera petición con esa clave todavía no terminó
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+type IdempotencyService struct {
+	repo     *repository.IdempotencyRepository
+	lease    time.Duration
+	timeouts config.TimeoutsConfig
+}
+
+func NewIdempotencyService(repo *repository.IdempotencyRepository, cfg config.IdempotencyConfig, timeouts config.TimeoutsConfig) *IdempotencyService {
+	return &IdempotencyService{repo: repo, lease: cfg.Lease, timeouts: timeouts}
+}
+
+// Begin reserva la clave para esta petición. Si ya existe una respuesta guardada
+// para la misma petición la devuelve (replay != nil); si la clave se usó con otra
+// petición devuelve ErrIdempotencyKeyReused. La misma petición incluye la precondición
+// If-Match: con otra versión esperada no es un reintento.
+// Si la primera petición quedó sin terminar (p. ej. el proceso se cayó) y venció su reserva,
+// esta petición la toma y se procesa.
+func (s *IdempotencyService) Begin(ctx context.Context, scope string, key string, method string, path string, ifMatch string, body []byte) (id string, replay *model.IdempotencyRecord, err error) {
+	hash := sha256.New()
+	hash.Write([]byte(method + "\n" + path + "\n"))
+	if ifMatch != "" {
+		hash.Write([]byte("If-Match: " + ifMatch + "\n"))
+	}
+	hash.Write(body)
+
+	now := time.Now()
+	rec := model.IdempotencyRecord{
+		ID:          scope + "|" + key,
+		Key:         key,
+		Scope:       scope,
+		RequestHash: hex.EncodeToString(hash.Sum(nil)),
+		LockedUntil: now.Add(s.lease),
+		CreatedAt:   now,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+	stored, reserved, err := s.repo.Reserve(ctx, rec)
+	if err != nil {
+		return "", nil, err
+	}
+	if reserved {
+		return rec.ID, nil, nil
+	}
+	if stored.RequestHash != rec.RequestHash {
+		return "", nil, ErrIdempotencyKeyReused
+	}
+	if !stored.Completed {
+		if stored.LockedUntil.After(now) {
+			return "", nil, ErrIdempotencyInProgress
+		}
+		err := s.repo.TakeOver(ctx, rec.ID, rec.RequestHash, rec.LockedUntil)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// otra petición la tomó o la completó mientras tanto
+			return "", nil, ErrIdempotencyInProgress
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return rec.ID, nil, nil
+	}
+	return "", &stored, nil
+}
+
+// Complete guarda la respuesta (código, cabeceras y cuerpo) para futuros reintentos con la misma clave
+func (s *IdempotencyService) Complete(ctx context.Context, id string, statusCode int, contentType string, headers map[string][]string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.repo.Complete(ctx, id, statusCode, contentType, headers, body)
+}
+
+// Release libera la clave (p. ej. tras un error del servidor) para que el reintento se procese
+func (s *IdempotencyService) Release(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.repo.Release(ctx, id)
+}
@@ -0,0 +1,301 @@
+// jwks_verifier.go
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Se devuelve cuando no se pudieron obtener las claves públicas del servicio de autenticación
+var ErrJWKSUnavailable = errors.New("jwks unavailable")
+
+// Tolerancia de reloj al validar exp / nbf
+const jwtLeeway = 30 * time.Second
+
+// Tiempo mínimo entre dos descargas del JWKS por un kid desconocido
+const jwksMinRefetch = 30 * time.Second
+
+// JWKSVerifier valida JWT RS256 localmente con las claves publicadas por el servicio de autenticación
+type JWKSVerifier struct {
+	url      string
+	audience string
+	issuer   string
+	refresh  time.Duration
+	client   *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+
+	// descargas concurrentes por un kid desconocido comparten una sola llamada
+	refreshing singleflight.Group
+}
+
+func NewJWKSVerifier(url, audience, issuer string, refresh, timeout time.Duration) *JWKSVerifier {
+	return &JWKSVerifier{
+		url:      url,
+		audience: audience,
+		issuer:   issuer,
+		refresh:  refresh,
+		client:   &http.Client{Timeout: timeout},
+		keys:     map[string]*rsa.PublicKey{},
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Claims que emite el servicio de autenticación
+type jwtClaims struct {
+	Sub         string          `json:"sub"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Login       string          `json:"login"`
+	Permissions []string        `json:"permissions"`
+	Enabled     *bool           `json:"enabled"`
+	Issuer      string          `json:"iss"`
+	Audience    json.RawMessage `json:"aud"`
+	ExpiresAt   *int64          `json:"exp"`
+	NotBefore   *int64          `json:"nbf"`
+}
+
+type jwkSet struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// Verify valida firma, exp, nbf, aud (e iss si está configurado) y arma el AuthUser desde los claims
+func (v *JWKSVerifier) Verify(ctx context.Context, token string) (*AuthUser, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported token algorithm '%s'", header.Alg)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errors.New("invalid token signature")
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	if err := v.validateClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	user := &AuthUser{
+		ID:          claims.ID,
+		Name:        claims.Name,
+		Login:       claims.Login,
+		Permissions: claims.Permissions,
+		Enabled:     claims.Enabled == nil || *claims.Enabled,
+		expiresAt:   time.Unix(*claims.ExpiresAt, 0),
+	}
+	if user.ID == "" {
+		user.ID = claims.Sub
+	}
+	if !user.Enabled {
+		return nil, errors.New("user disabled")
+	}
+	return user, nil
+}
+
+func (v *JWKSVerifier) validateClaims(claims jwtClaims, now time.Time) error {
+	if claims.ExpiresAt == nil {
+		return errors.New("token without exp")
+	}
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return errors.New("token not valid yet")
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return errors.New("invalid token issuer")
+	}
+	if !slices.Contains(audiences(claims.Audience), v.audience) {
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
+
+// aud puede venir como string o como lista
+func audiences(raw json.RawMessage) []string {
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}
+	}
+	var many []string
+	_ = json.Unmarshal(raw, &many)
+	return many
+}
+
+// Devuelve la clave del kid; vuelve a descargar el JWKS si está vencido o si el kid es
+// desconocido (rotación de claves), como mucho una vez cada jwksMinRefetch
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	age := time.Since(v.fetchedAt)
+	v.mu.RUnlock()
+
+	if ok && age < v.refresh {
+		return key, nil
+	}
+	if !ok && age < jwksMinRefetch {
+		return nil, fmt.Errorf("unknown token key '%s'", kid)
+	}
+
+	if err := v.refreshShared(ctx); err != nil {
+		if ok {
+			// mejor una clave algo vieja que rechazar todos los tokens
+			return key, nil
+		}
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown token key '%s'", kid)
+}
+
+// Igual que Refresh, pero las llamadas concurrentes comparten una sola descarga, que no se
+// cancela si se desconecta el cliente que la inició
+func (v *JWKSVerifier) refreshShared(ctx context.Context) error {
+	flight := v.refreshing.DoChan("jwks", func() (interface{}, error) {
+		return nil, v.Refresh(context.WithoutCancel(ctx))
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-flight:
+		return res.Err
+	}
+}
+
+// Refresh descarga el JWKS y reemplaza las claves en caché
+func (v *JWKSVerifier) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrJWKSUnavailable, resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := rsaPublicKey(k.N, k.E)
+		if err != nil {
+			log.Printf("⚠️ Skipping invalid JWKS key '%s': %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// Run refresca el JWKS periódicamente hasta que se cancele el contexto
+func (v *JWKSVerifier) Run(ctx context.Context) {
+	if err := v.Refresh(ctx); err != nil {
+		log.Printf("⚠️ JWKS refresh failed: %v", err)
+	}
+
+	ticker := time.NewTicker(v.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.Refresh(ctx); err != nil {
+				log.Printf("⚠️ JWKS refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+func rsaPublicKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	exp := new(big.Int).SetBytes(eb)
+	if !exp.IsInt64() || exp.Int64() < 3 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exp.Int64())}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,381 @@
+// jwks_verifier_test.go
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"order-status-service/internal/config"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testAudience = "order-status-service"
+	testIssuer   = "https://auth.example.com"
+)
+
+// Claves RSA de prueba, generadas una sola vez por ejecución
+var (
+	testKeysOnce sync.Once
+	testKeys     [2]*rsa.PrivateKey
+)
+
+func testKey(t *testing.T, i int) *rsa.PrivateKey {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		for j := range testKeys {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			testKeys[j] = key
+		}
+	})
+	return testKeys[i]
+}
+
+// Servicio de autenticación falso que publica un JWKS y cuenta las descargas
+type fakeJWKS struct {
+	mu     sync.Mutex
+	keys   map[string]*rsa.PublicKey
+	status int
+	calls  atomic.Int32
+}
+
+func newFakeJWKS(t *testing.T, keys map[string]*rsa.PublicKey) (*fakeJWKS, *httptest.Server) {
+	fake := &fakeJWKS{keys: keys, status: http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.calls.Add(1)
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		if fake.status != http.StatusOK {
+			w.WriteHeader(fake.status)
+			return
+		}
+		set := map[string][]map[string]string{"keys": {}}
+		for kid, key := range fake.keys {
+			set["keys"] = append(set["keys"], map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+	return fake, srv
+}
+
+// Reemplaza las claves publicadas (rotación)
+func (f *fakeJWKS) publish(keys map[string]*rsa.PublicKey) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = keys
+}
+
+// Claims válidos para el verificador de prueba; cada caso modifica los que necesita
+func testClaims(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":         "user-1",
+		"name":        "Ana",
+		"login":       "ana",
+		"permissions": []string{"user"},
+		"iss":         testIssuer,
+		"aud":         testAudience,
+		"exp":         now.Add(time.Hour).Unix(),
+		"nbf":         now.Add(-time.Minute).Unix(),
+	}
+}
+
+// Firma un JWT RS256 con la clave y el kid indicados (alg se puede forzar para los casos inválidos)
+func signToken(t *testing.T, key *rsa.PrivateKey, kid, alg string, claims map[string]interface{}) string {
+	t.Helper()
+	segment := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := segment(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + segment(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestJWKSVerifier(url string) *JWKSVerifier {
+	return NewJWKSVerifier(url, testAudience, testIssuer, time.Hour, time.Second)
+}
+
+func TestJWKSVerify(t *testing.T) {
+	key := testKey(t, 0)
+	_, srv := newFakeJWKS(t, map[string]*rsa.PublicKey{"k1": &key.PublicKey})
+	now := time.Now()
+
+	with := func(edit func(c map[string]interface{})) map[string]interface{} {
+		c := testClaims(now)
+		edit(c)
+		return c
+	}
+	tampered := func() string {
+		token := signToken(t, key, "k1", "RS256", testClaims(now))
+		parts := strings.Split(token, ".")
+		c := testClaims(now)
+		c["permissions"] = []string{"admin"}
+		b, _ := json.Marshal(c)
+		return parts[0] + "." + base64.RawURLEncoding.EncodeToString(b) + "." + parts[2]
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid", signToken(t, key, "k1", "RS256", testClaims(now)), ""},
+		{"tampered claims", tampered(), "invalid token signature"},
+		{"signed with another key", signToken(t, testKey(t, 1), "k1", "RS256", testClaims(now)), "invalid token signature"},
+		{"HS256", signToken(t, key, "k1", "HS256", testClaims(now)), "unsupported token algorithm"},
+		{"alg none", signToken(t, key, "k1", "none", testClaims(now)), "unsupported token algorithm"},
+		{"malformed", "not-a-token", "malformed token"},
+		{"expired within the leeway", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["exp"] = now.Add(-jwtLeeway / 2).Unix()
+		})), ""},
+		{"expired", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["exp"] = now.Add(-2 * jwtLeeway).Unix()
+		})), "token expired"},
+		{"without exp", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) { delete(c, "exp") })), "token without exp"},
+		{"nbf within the leeway", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["nbf"] = now.Add(jwtLeeway / 2).Unix()
+		})), ""},
+		{"not valid yet", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["nbf"] = now.Add(2 * jwtLeeway).Unix()
+		})), "token not valid yet"},
+		{"audience in a list", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["aud"] = []string{"other-service", testAudience}
+		})), ""},
+		{"other audience", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["aud"] = "other-service"
+		})), "invalid token audience"},
+		{"without audience", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) { delete(c, "aud") })), "invalid token audience"},
+		{"other issuer", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example.com"
+		})), "invalid token issuer"},
+		{"disabled user", signToken(t, key, "k1", "RS256", with(func(c map[string]interface{}) {
+			c["enabled"] = false
+		})), "user disabled"},
+	}
+	v := newTestJWKSVerifier(srv.URL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() = %v, want nil", err)
+				}
+				if user.ID != "user-1" || user.Login != "ana" || !user.Enabled {
+					t.Errorf("Verify() user = %+v, want user-1 (ana, enabled)", user)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Mueve la última descarga del JWKS hacia atrás, como si hubiera pasado ese tiempo
+func ageJWKS(v *JWKSVerifier, d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.fetchedAt = v.fetchedAt.Add(-d)
+}
+
+func TestJWKSUnknownKidRefetch(t *testing.T) {
+	key := testKey(t, 0)
+	fake, srv := newFakeJWKS(t, map[string]*rsa.PublicKey{"k1": &key.PublicKey})
+	v := newTestJWKSVerifier(srv.URL)
+	unknown := signToken(t, key, "k2", "RS256", testClaims(time.Now()))
+
+	// la primera validación descarga el JWKS; el kid sigue sin aparecer
+	for i, wantCalls := range []int32{1, 1, 1} {
+		if _, err := v.Verify(context.Background(), unknown); err == nil || !strings.Contains(err.Error(), "unknown token key") {
+			t.Fatalf("Verify() #%d = %v, want unknown token key", i+1, err)
+		}
+		if calls := fake.calls.Load(); calls != wantCalls {
+			t.Fatalf("after Verify() #%d JWKS downloaded %d times, want %d (at most once per jwksMinRefetch)", i+1, calls, wantCalls)
+		}
+	}
+
+	ageJWKS(v, jwksMinRefetch)
+	if _, err := v.Verify(context.Background(), unknown); err == nil {
+		t.Fatalf("Verify() = nil, want unknown token key")
+	}
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Errorf("JWKS downloaded %d times after jwksMinRefetch, want 2", calls)
+	}
+}
+
+func TestJWKSKeyRotation(t *testing.T) {
+	oldKey, newKey := testKey(t, 0), testKey(t, 1)
+	fake, srv := newFakeJWKS(t, map[string]*rsa.PublicKey{"k1": &oldKey.PublicKey})
+	v := newTestJWKSVerifier(srv.URL)
+	now := time.Now()
+
+	if _, err := v.Verify(context.Background(), signToken(t, oldKey, "k1", "RS256", testClaims(now))); err != nil {
+		t.Fatalf("Verify() with the current key = %v, want nil", err)
+	}
+
+	// el servicio de autenticación rota a k2 y deja de publicar k1
+	fake.publish(map[string]*rsa.PublicKey{"k2": &newKey.PublicKey})
+	ageJWKS(v, jwksMinRefetch)
+
+	if _, err := v.Verify(context.Background(), signToken(t, newKey, "k2", "RS256", testClaims(now))); err != nil {
+		t.Fatalf("Verify() with the rotated key = %v, want nil", err)
+	}
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Errorf("JWKS downloaded %d times, want 2", calls)
+	}
+	if _, err := v.Verify(context.Background(), signToken(t, oldKey, "k1", "RS256", testClaims(now))); err == nil {
+		t.Errorf("Verify() with the retired key = nil, want an error")
+	}
+}
+
+func TestJWKSStaleKeyWhenUnavailable(t *testing.T) {
+	key := testKey(t, 0)
+	fake, srv := newFakeJWKS(t, map[string]*rsa.PublicKey{"k1": &key.PublicKey})
+	v := newTestJWKSVerifier(srv.URL)
+	token := signToken(t, key, "k1", "RS256", testClaims(time.Now()))
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+
+	// JWKS vencido y el servicio caído: se sigue usando la clave conocida
+	fake.mu.Lock()
+	fake.status = http.StatusServiceUnavailable
+	fake.mu.Unlock()
+	ageJWKS(v, 2*time.Hour)
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Errorf("Verify() with a stale JWKS = %v, want the known key to be used", err)
+	}
+	if _, err := v.Verify(context.Background(), signToken(t, key, "k2", "RS256", testClaims(time.Now()))); !errors.Is(err, ErrJWKSUnavailable) {
+		t.Errorf("Verify() with an unknown kid = %v, want ErrJWKSUnavailable", err)
+	}
+}
+
+// Servicio de autenticación falso para el modo remoto: GET /users/current responde el usuario
+// o el código configurado, y cuenta las llamadas. Si hold no es nil, cada respuesta espera a que se cierre.
+type fakeAuth struct {
+	status atomic.Int32
+	calls  atomic.Int32
+	hold   chan struct{}
+}
+
+func newFakeAuth(t *testing.T, status int) (*fakeAuth, *httptest.Server) {
+	fake := &fakeAuth{}
+	fake.status.Store(int32(status))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.calls.Add(1)
+		if fake.hold != nil {
+			<-fake.hold
+		}
+		if r.URL.Path != "/users/current" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if status := int(fake.status.Load()); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(AuthUser{ID: "user-1", Login: "ana", Permissions: []string{"user"}, Enabled: true})
+	}))
+	t.Cleanup(srv.Close)
+	return fake, srv
+}
+
+func TestAuthServiceJWKSFallback(t *testing.T) {
+	key := testKey(t, 0)
+	valid := signToken(t, key, "k1", "RS256", testClaims(time.Now()))
+	tests := []struct {
+		name           string
+		jwksStatus     int
+		remoteFallback bool
+		token          string
+		wantErr        error
+		wantRemote     int32
+	}{
+		{"jwks available", http.StatusOK, true, valid, nil, 0},
+		{"jwks unavailable with fallback", http.StatusInternalServerError, true, valid, nil, 1},
+		{"jwks unavailable without fallback", http.StatusInternalServerError, false, valid, ErrJWKSUnavailable, 0},
+		// un token rechazado localmente nunca pasa al modo remoto
+		{"invalid token", http.StatusOK, true, signToken(t, testKey(t, 1), "k1", "RS256", testClaims(time.Now())), ErrInvalidToken, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwks, jwksSrv := newFakeJWKS(t, map[string]*rsa.PublicKey{"k1": &key.PublicKey})
+			jwks.status = tt.jwksStatus
+			remote, authSrv := newFakeAuth(t, http.StatusOK)
+			a := NewAuthService(config.AuthConfig{
+				URL:            authSrv.URL,
+				Mode:           AuthModeJWKS,
+				JWKSURL:        jwksSrv.URL,
+				Audience:       testAudience,
+				Issuer:         testIssuer,
+				JWKSRefresh:    time.Hour,
+				RemoteFallback: tt.remoteFallback,
+				Timeout:        time.Second,
+			})
+
+			user, err := a.ValidateToken(context.Background(), tt.token)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateToken() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.ID != "user-1" {
+				t.Errorf("ValidateToken() user = %q, want user-1", user.ID)
+			}
+			if calls := remote.calls.Load(); calls != tt.wantRemote {
+				t.Errorf("auth service got %d requests, want %d", calls, tt.wantRemote)
+			}
+		})
+	}
+}
+
+func TestAuthServiceCanceledIsNotInvalidToken(t *testing.T) {
+	key := testKey(t, 0)
+	_, srv := newFakeJWKS(t, map[string]*rsa.PublicKey{"k1": &key.PublicKey})
+	a := NewAuthService(config.AuthConfig{
+		Mode:        AuthModeJWKS,
+		JWKSURL:     srv.URL,
+		Audience:    testAudience,
+		Issuer:      testIssuer,
+		JWKSRefresh: time.Hour,
+		Timeout:     time.Second,
+	})
+
+	// el cliente se desconectó mientras se descargaba el JWKS
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := a.ValidateToken(ctx, signToken(t, key, "k1", "RS256", testClaims(time.Now())))
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateToken() = %v, want context.Canceled and not ErrInvalidToken", err)
+	}
+}
@@ -4,33 +4,80 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"order-status-service/internal/config"
 	"order-status-service/internal/dto"
+	"order-status-service/internal/i18n"
 	"order-status-service/internal/mapper"
 	"order-status-service/internal/model"
+	"order-status-service/internal/policy"
 	"order-status-service/internal/repository"
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Se devuelve cuando el cliente envía If-Match con una versión que ya no es la actual
+var ErrPreconditionFailed = errors.New("order status version does not match If-Match")
+
+// Se devuelve cuando los parámetros de un listado no son válidos
+var ErrInvalidQuery = errors.New("invalid query")
+
+// OrderStatusStore guarda los estados de las órdenes y su historial (lo implementa
+// repository.OrderStatusRepository). Las búsquedas devuelven mongo.ErrNoDocuments si no hay resultado.
+type OrderStatusStore interface {
+	Create(ctx context.Context, status model.OrderStatus) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (model.OrderStatus, error)
+	FindByOrderID(ctx context.Context, orderID string) (model.OrderStatus, error)
+	UpdateStatusWithEntry(ctx context.Context, current model.OrderStatus, statusID primitive.ObjectID, statusName string, entry model.StatusEntry) (model.OrderStatus, error)
+	UpdateShipping(ctx context.Context, current model.OrderStatus, shipping model.ShippingInfo, revision model.ShippingRevision) (model.OrderStatus, error)
+	GetBaseStatuses(ctx context.Context) ([]string, error)
+	FindPage(ctx context.Context, q repository.OrderStatusQuery) ([]model.OrderStatus, string, error)
+	FindHistory(ctx context.Context, q repository.HistoryQuery) ([]model.StatusHistoryRecord, string, error)
+}
+
+// StatusCatalogReader lee el catálogo de estados (lo implementa repository.CatalogRepository)
+type StatusCatalogReader interface {
+	GetAll(ctx context.Context) ([]model.StatusCatalog, error)
+	ExistsByID(ctx context.Context, id primitive.ObjectID) (bool, error)
+	ExistsByName(ctx context.Context, name string) (bool, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (model.StatusCatalog, error)
+	FindByName(ctx context.Context, name string) (model.StatusCatalog, error)
+	FindInitial(ctx context.Context) (model.StatusCatalog, error)
+}
+
 // OrderStatusService es el servicio principal para manejar estados de órdenes
 type OrderStatusService struct {
-	repo *repository.OrderStatusRepository
-	// nota: requiere que CatalogRepository tenga los métodos FindByID / ExistsByID
-	catalogRepo *repository.CatalogRepository
+	repo        OrderStatusStore
+	catalogRepo StatusCatalogReader
+	broker      *StatusBroker
+	orders      *OrdersClient
+	timeouts    config.TimeoutsConfig
 }
 
-func NewOrderStatusService(repo *repository.OrderStatusRepository) *OrderStatusService {
+func NewOrderStatusService(repo OrderStatusStore, catalogRepo StatusCatalogReader, broker *StatusBroker, orders *OrdersClient, timeouts config.TimeoutsConfig) *OrderStatusService {
 	return &OrderStatusService{
 		repo:        repo,
-		catalogRepo: repository.NewCatalogRepository(repo.Collection.Database()),
+		catalogRepo: catalogRepo,
+		broker:      broker,
+		orders:      orders,
+		timeouts:    timeouts,
 	}
 }
 
 // CreateStatus crea un nuevo documento OrderStatus (usado para inicialización)
 // Acepta StatusID (preferido) o Status (nombre) en la request.
-func (s *OrderStatusService) CreateStatus(req dto.CreateOrderStatusRequest) (dto.OrderStatusDTO, error) {
-	ctx := context.Background()
+// El actor (servicio o admin que crea la orden) queda registrado en la entrada inicial del historial.
+// La orden se valida antes contra el servicio de órdenes (ver OrdersClient.VerifyOrder).
+func (s *OrderStatusService) CreateStatus(ctx context.Context, req dto.CreateOrderStatusRequest, actor policy.Actor) (dto.OrderStatusDTO, error) {
+	// fuera del plazo de escritura: el cliente de órdenes tiene su propio timeout por intento
+	if err := s.orders.VerifyOrder(ctx, req); err != nil {
+		return dto.OrderStatusDTO{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
 
 	// Resolver id y nombre del estado: priorizar StatusID si se envía
 	var statusID primitive.ObjectID
@@ -38,7 +85,7 @@ func (s *OrderStatusService) CreateStatus(req dto.CreateOrderStatusRequest) (dto
 	if req.StatusID != "" {
 		id, err := primitive.ObjectIDFromHex(req.StatusID)
 		if err != nil {
-			return dto.OrderStatusDTO{}, fmt.Errorf("invalid status_id: %v", err)
+			return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "status.invalid_id_value", req.StatusID)
 		}
 		// verificar existencia en catálogo
 		exists, err := s.catalogRepo.ExistsByID(ctx, id)
@@ -46,7 +93,7 @@ func (s *OrderStatusService) CreateStatus(req dto.CreateOrderStatusRequest) (dto
 			return dto.OrderStatusDTO{}, err
 		}
 		if !exists {
-			return dto.OrderStatusDTO{}, fmt.Errorf("status id %s not found in catalog", req.StatusID)
+			return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "status.id_not_in_catalog", req.StatusID)
 		}
 		statusID = id
 		// obtener nombre
@@ -54,52 +101,51 @@ func (s *OrderStatusService) CreateStatus(req dto.CreateOrderStatusRequest) (dto
 		if err != nil {
 			return dto.OrderStatusDTO{}, err
 		}
+		if cat.Archived {
+			return dto.OrderStatusDTO{}, NewError(ErrStatusArchived, "status.archived", cat.Name)
+		}
 		statusName = cat.Name
 	} else if req.Status != "" {
 		// alternativa: aceptar nombre de estado (compatibilidad hacia atrás)
-		exists, err := s.catalogRepo.ExistsByName(req.Status)
+		exists, err := s.catalogRepo.ExistsByName(ctx, req.Status)
 		if err != nil {
 			return dto.OrderStatusDTO{}, err
 		}
 		if !exists {
-			return dto.OrderStatusDTO{}, fmt.Errorf("status '%s' does not exist in catalog", req.Status)
+			return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "status.name_not_in_catalog", req.Status)
 		}
 		// buscar id por nombre
 		cat, err := s.catalogRepo.FindByName(ctx, req.Status)
 		if err != nil {
 			return dto.OrderStatusDTO{}, err
 		}
+		if cat.Archived {
+			return dto.OrderStatusDTO{}, NewError(ErrStatusArchived, "status.archived", cat.Name)
+		}
 		statusID = cat.ID
 		statusName = cat.Name
 	} else {
-		// valor por defecto: Pendiente
-		cat, err := s.catalogRepo.FindByName(ctx, "Pendiente")
+		// valor por defecto: el estado marcado como inicial en el catálogo
+		cat, err := s.catalogRepo.FindInitial(ctx)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return dto.OrderStatusDTO{}, NewError(ErrCatalogInconsistent, "catalog.initial_missing")
+		}
 		if err != nil {
-			return dto.OrderStatusDTO{}, errors.New("default status 'Pendiente' not found")
+			return dto.OrderStatusDTO{}, fmt.Errorf("find initial status: %w", err)
 		}
 		statusID = cat.ID
 		statusName = cat.Name
 	}
 
-	// Prevenir múltiples order_status para la misma orden (idempotencia en inicialización)
+	// Prevenir múltiples order_status para la misma orden (idempotencia en inicialización):
+	// si ya existe se devuelve el documento existente
 	if req.OrderID != "" {
-		exists, err := s.repo.ExistsByOrderID(context.Background(), req.OrderID)
-		if err != nil {
-			return dto.OrderStatusDTO{}, err
+		existing, err := s.repo.FindByOrderID(ctx, req.OrderID)
+		if err == nil {
+			return mapper.ToOrderStatusDTO(existing), nil
 		}
-		if exists {
-			// buscar documento existente y retornarlo
-			// intento de búsqueda por order id (no existe método directo FindByOrderID, se usa FindAll)
-			all, err := s.repo.FindAll(context.Background())
-			if err == nil {
-				for _, o := range all {
-					if o.OrderID == req.OrderID {
-						return mapper.ToOrderStatusDTO(o), nil
-					}
-				}
-			}
-			// alternativa: conflicto
-			return dto.OrderStatusDTO{}, fmt.Errorf("order_status for order_id %s already exists", req.OrderID)
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return dto.OrderStatusDTO{}, err
 		}
 	}
 
@@ -108,53 +154,81 @@ func (s *OrderStatusService) CreateStatus(req dto.CreateOrderStatusRequest) (dto
 		ID:        primitive.NewObjectID(),
 		OrderID:   req.OrderID,
 		UserID:    req.UserID,
+		SellerIDs: req.SellerIDs,
 		StatusID:  statusID,
 		Status:    statusName,
 		Shipping:  mapper.ToShippingEntity(req.Shipping),
+		Version:   1,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		History: []model.StatusEntry{
 			{
 				ID:     primitive.NewObjectID(),
 				Status: statusName,
-				UserID: req.UserID,
-				Role:   "system",
+				UserID: actor.ID,
+				Role:   string(actor.Role),
 				Reason: "initial",
 				At:     time.Now(),
 			},
 		},
 	}
 
-	if err := s.repo.Create(context.Background(), entity); err != nil {
+	if err := s.repo.Create(ctx, entity); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return dto.OrderStatusDTO{}, NewError(ErrConflict, "order_status.exists")
+		}
+		if errors.Is(err, repository.ErrStatusNotAssignable) {
+			return dto.OrderStatusDTO{}, NewError(ErrStatusArchived, "status.archived", statusName)
+		}
 		return dto.OrderStatusDTO{}, err
 	}
 	return mapper.ToOrderStatusDTO(entity), nil
 }
 
-// ChangeStatus cambia el estado actual aplicando reglas de negocio
-func (s *OrderStatusService) ChangeStatus(orderStatusID string, newStatusID string, actorID string, actorRole string, reason string) (dto.OrderStatusDTO, error) {
-	ctx := context.Background()
+// ChangeStatus cambia el estado actual aplicando reglas de negocio.
+// Si expectedVersions no es nil (cabecera If-Match), el documento debe estar en alguna de esas versiones.
+// Si otro cambio se aplica entre la lectura y la escritura, devuelve repository.ErrVersionConflict.
+func (s *OrderStatusService) ChangeStatus(ctx context.Context, orderStatusID string, newStatusID string, actor policy.Actor, reason string, expectedVersions []int64) (dto.OrderStatusDTO, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(orderStatusID)
 	if err != nil {
-		return dto.OrderStatusDTO{}, fmt.Errorf("invalid order status id")
+		return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "order_status.invalid_id")
 	}
 	newID, err := primitive.ObjectIDFromHex(newStatusID)
 	if err != nil {
-		return dto.OrderStatusDTO{}, fmt.Errorf("invalid new status id")
+		return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "status.invalid_new_id")
 	}
 
 	// resolver el nombre del nuevo estado desde el catálogo
 	cat, err := s.catalogRepo.FindByID(ctx, newID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "status.id_not_in_catalog", newStatusID)
+	}
 	if err != nil {
 		return dto.OrderStatusDTO{}, err
 	}
+	if cat.Archived {
+		return dto.OrderStatusDTO{}, NewError(ErrStatusArchived, "status.archived", cat.Name)
+	}
 	newName := cat.Name
 
 	// buscar documento existente
 	doc, err := s.repo.FindByID(ctx, objID)
 	if err != nil {
-		return dto.OrderStatusDTO{}, err
+		return dto.OrderStatusDTO{}, notFound(err, "order_status.not_found")
+	}
+
+	// El rol se resuelve contra la orden: el cliente debe ser el dueño y el vendedor estar asignado.
+	// Quien no puede ver la orden recibe el mismo 404 que si no existiera, para no revelar sus ids
+	roles, err := policy.RolesFor(actor, policy.Order{OwnerID: doc.UserID, SellerIDs: doc.SellerIDs})
+	if err != nil {
+		return dto.OrderStatusDTO{}, NewError(ErrNotFound, "order_status.not_found")
+	}
+
+	if expectedVersions != nil && !slices.Contains(expectedVersions, doc.Version) {
+		return dto.OrderStatusDTO{}, ErrPreconditionFailed
 	}
 
 	// Idempotencia: si es el mismo status_id -> retornar sin cambios
@@ -162,115 +236,424 @@ func (s *OrderStatusService) ChangeStatus(orderStatusID string, newStatusID stri
 		return mapper.ToOrderStatusDTO(doc), nil
 	}
 
-	// Estados terminales donde no se permiten cambios
-	terminal := map[string]bool{
-		"Cancelado": true,
-		"Entregado": true,
-		"Rechazado": true,
+	// REGLAS DE NEGOCIO: se validan contra el grafo de transiciones del catálogo
+	current, err := s.catalogRepo.FindByID(ctx, doc.StatusID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return dto.OrderStatusDTO{}, NewError(ErrCatalogInconsistent, "catalog.current_status_missing", doc.Status)
 	}
-	if terminal[doc.Status] {
-		return dto.OrderStatusDTO{}, fmt.Errorf("cannot change status from terminal state '%s'", doc.Status)
+	if err != nil {
+		return dto.OrderStatusDTO{}, fmt.Errorf("find current status '%s': %w", doc.Status, err)
 	}
-
-	// REGLAS DE NEGOCIO
-	// Cancelado -> solo cliente y solo si el estado actual NO es Enviado/Entregado/Rechazado
-	if newName == "Cancelado" {
-		if actorRole != "client" {
-			return dto.OrderStatusDTO{}, fmt.Errorf("only client can cancel the order")
-		}
-		forbidden := map[string]bool{"Enviado": true, "Entregado": true, "Rechazado": true}
-		if forbidden[doc.Status] {
-			return dto.OrderStatusDTO{}, fmt.Errorf("cannot cancel when current status is '%s'", doc.Status)
-		}
+	if current.IsTerminal {
+		return dto.OrderStatusDTO{}, NewError(ErrTerminalState, "order_status.terminal", doc.Status)
 	}
 
-	// Rechazado -> solo admin o seller y solo si el estado actual NO es Enviado o Cancelado
-	if newName == "Rechazado" {
-		if actorRole != "admin" && actorRole != "seller" {
-			return dto.OrderStatusDTO{}, fmt.Errorf("only admin or seller can reject the order")
-		}
-		forbidden := map[string]bool{"Enviado": true, "Cancelado": true}
-		if forbidden[doc.Status] {
-			return dto.OrderStatusDTO{}, fmt.Errorf("cannot reject when current status is '%s'", doc.Status)
-		}
+	transition, ok := findTransition(current, newID)
+	if !ok {
+		return dto.OrderStatusDTO{}, NewError(ErrTransitionNotAllowed, "order_status.transition_not_allowed", doc.Status, newName)
+	}
+	// la transición se evalúa con todos los roles del actor (p. ej. un vendedor que es dueño puede cancelar)
+	role, ok := policy.TransitionRole(roles, transition.Roles)
+	if !ok {
+		return dto.OrderStatusDTO{}, NewError(ErrForbiddenTransition, "order_status.transition_forbidden", roles[0], doc.Status, newName)
 	}
 
 	// Todas las validaciones pasaron — construir entrada de historial y actualizar
 	entry := model.StatusEntry{
 		ID:     primitive.NewObjectID(),
 		Status: newName,
-		UserID: actorID,
-		Role:   actorRole,
+		UserID: actor.ID,
+		Role:   string(role),
 		Reason: reason,
 		At:     time.Now(),
 	}
 
-	if err := s.repo.UpdateStatusWithEntry(ctx, objID, newID, newName, entry); err != nil {
+	updated, err := s.repo.UpdateStatusWithEntry(ctx, doc, newID, newName, entry)
+	if errors.Is(err, repository.ErrStatusNotAssignable) {
+		// el destino se archivó (o eliminó) después de validarlo
+		return dto.OrderStatusDTO{}, NewError(ErrStatusArchived, "status.archived", newName)
+	}
+	if err != nil {
 		return dto.OrderStatusDTO{}, err
 	}
 
-	// retornar documento actualizado (volver a buscar)
-	updated, err := s.repo.FindByID(ctx, objID)
+	payload := model.OrderStatusEventPayload{
+		OrderStatusID:    doc.ID.Hex(),
+		OrderID:          doc.OrderID,
+		UserID:           doc.UserID,
+		StatusID:         newID.Hex(),
+		Status:           newName,
+		PreviousStatusID: doc.StatusID.Hex(),
+		PreviousStatus:   doc.Status,
+		Entry:            &entry,
+	}
+	// Los webhooks de los partners y el aviso al servicio de órdenes (si la orden terminó) los
+	// crea el relay a partir del evento del outbox. Avisar a los clientes que siguen la orden en vivo
+	s.broker.Publish(doc.ID.Hex(), payload)
+
+	return mapper.ToOrderStatusDTO(updated), nil
+}
+
+// UpdateShipping corrige la dirección de envío mientras la orden no se haya despachado, es decir,
+// mientras su estado sea de categoría pending o in_progress. Solo pueden hacerlo el dueño y los admins.
+// Cada corrección queda en shipping_history con la dirección anterior, la nueva, el actor y la fecha.
+// If-Match y los cambios concurrentes se manejan igual que en ChangeStatus.
+func (s *OrderStatusService) UpdateShipping(ctx context.Context, orderStatusID string, req dto.UpdateShippingRequest, actor policy.Actor, expectedVersions []int64) (dto.OrderStatusDTO, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(orderStatusID)
+	if err != nil {
+		return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "order_status.invalid_id")
+	}
+
+	doc, err := s.repo.FindByID(ctx, objID)
+	if err != nil {
+		return dto.OrderStatusDTO{}, notFound(err, "order_status.not_found")
+	}
+
+	order := policy.Order{OwnerID: doc.UserID, SellerIDs: doc.SellerIDs}
+	if !policy.CanView(actor, order) {
+		return dto.OrderStatusDTO{}, NewError(ErrNotFound, "order_status.not_found")
+	}
+	// un vendedor asignado ve la orden pero no puede corregir la dirección
+	role, err := policy.RoleForShipping(actor, order)
+	if err != nil {
+		return dto.OrderStatusDTO{}, NewError(policy.ErrForbidden, "auth.not_owner")
+	}
+
+	if expectedVersions != nil && !slices.Contains(expectedVersions, doc.Version) {
+		return dto.OrderStatusDTO{}, ErrPreconditionFailed
+	}
+
+	current, err := s.catalogRepo.FindByID(ctx, doc.StatusID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return dto.OrderStatusDTO{}, NewError(ErrCatalogInconsistent, "catalog.current_status_missing", doc.Status)
+	}
+	if err != nil {
+		return dto.OrderStatusDTO{}, fmt.Errorf("find current status '%s': %w", doc.Status, err)
+	}
+	if !current.Category.IsPreShipment() {
+		return dto.OrderStatusDTO{}, NewError(ErrShippingLocked, "order_status.shipping_locked", doc.Status)
+	}
+
+	shipping := mapper.ApplyShippingUpdate(doc.Shipping, req)
+	required := []struct{ field, value string }{
+		{"address_line1", shipping.AddressLine1},
+		{"city", shipping.City},
+		{"country", shipping.Country},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "shipping.required_field", r.field)
+		}
+	}
+
+	// Sin cambios: no se registra una revisión vacía
+	if shipping == doc.Shipping {
+		return mapper.ToOrderStatusDTO(doc), nil
+	}
+
+	revision := model.ShippingRevision{
+		ID:     primitive.NewObjectID(),
+		Old:    doc.Shipping,
+		New:    shipping,
+		UserID: actor.ID,
+		Role:   string(role),
+		At:     time.Now(),
+	}
+
+	updated, err := s.repo.UpdateShipping(ctx, doc, shipping, revision)
 	if err != nil {
 		return dto.OrderStatusDTO{}, err
 	}
 
+	// Los webhooks los crea el relay a partir del evento del outbox. Avisar a los clientes que
+	// siguen la orden en vivo
+	s.broker.Publish(doc.ID.Hex(), model.OrderStatusEventPayload{
+		OrderStatusID: doc.ID.Hex(),
+		OrderID:       doc.OrderID,
+		UserID:        doc.UserID,
+		StatusID:      doc.StatusID.Hex(),
+		Status:        doc.Status,
+		Shipping:      &revision,
+	})
 	return mapper.ToOrderStatusDTO(updated), nil
 }
 
+// GetByID devuelve el estado de una orden por el id del documento
+func (s *OrderStatusService) GetByID(ctx context.Context, orderStatusID string) (dto.OrderStatusDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(orderStatusID)
+	if err != nil {
+		return dto.OrderStatusDTO{}, NewError(ErrInvalidInput, "order_status.invalid_id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	doc, err := s.repo.FindByID(ctx, objID)
+	if err != nil {
+		return dto.OrderStatusDTO{}, notFound(err, "order_status.not_found")
+	}
+	return mapper.ToOrderStatusDTO(doc), nil
+}
+
+// GetByOrderID devuelve el estado de una orden por su order_id
+func (s *OrderStatusService) GetByOrderID(ctx context.Context, orderID string) (dto.OrderStatusDTO, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	doc, err := s.repo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return dto.OrderStatusDTO{}, notFound(err, "order_status.not_found")
+	}
+	return mapper.ToOrderStatusDTO(doc), nil
+}
+
+// Subscribe suscribe al stream de cambios de una orden
+func (s *OrderStatusService) Subscribe(orderStatusID string) (<-chan model.OrderStatusEventPayload, func()) {
+	return s.broker.Subscribe(orderStatusID)
+}
+
 // Otros getters auxiliares reutilizando el repo
-func (s *OrderStatusService) GetAllStatuses() ([]string, error) {
-	return s.repo.GetBaseStatuses(context.Background())
+func (s *OrderStatusService) GetAllStatuses(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.repo.GetBaseStatuses(ctx)
+}
+
+// Tamaño de página por defecto y máximo de los listados
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ListOrderStatuses devuelve una página de estados de órdenes aplicando filtros y orden
+func (s *OrderStatusService) ListOrderStatuses(ctx context.Context, q dto.OrderStatusListQuery) (dto.OrderStatusPage, error) {
+	query, err := toRepositoryQuery(q)
+	if err != nil {
+		return dto.OrderStatusPage{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	statuses, next, err := s.repo.FindPage(ctx, query)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return dto.OrderStatusPage{}, NewError(ErrInvalidQuery, "query.invalid_cursor")
+	}
+	if err != nil {
+		return dto.OrderStatusPage{}, err
+	}
+	return dto.OrderStatusPage{Items: mapper.ToOrderStatusDTOs(statuses), NextCursor: next}, nil
+}
+
+// Valida los parámetros del listado y los traduce a la consulta del repositorio
+func toRepositoryQuery(q dto.OrderStatusListQuery) (repository.OrderStatusQuery, error) {
+	query := repository.OrderStatusQuery{
+		UserID:   q.UserID,
+		SellerID: q.SellerID,
+		Party:    q.Party,
+		Statuses: q.Status,
+		Country:  q.Country,
+		City:     q.City,
+		Cursor:   q.Cursor,
+		Sort:     repository.SortByCreatedAt,
+	}
+
+	switch q.Sort {
+	case "", repository.SortByCreatedAt:
+	case repository.SortByUpdatedAt:
+		query.Sort = repository.SortByUpdatedAt
+	default:
+		return query, NewError(ErrInvalidQuery, "query.invalid_sort")
+	}
+
+	switch q.Order {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return query, NewError(ErrInvalidQuery, "query.invalid_order")
+	}
+
+	limit, err := pageLimit(q.Limit)
+	if err != nil {
+		return query, err
+	}
+	query.Limit = limit
+
+	for _, id := range q.StatusID {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return query, NewError(ErrInvalidQuery, "status.invalid_id_value", id)
+		}
+		query.StatusIDs = append(query.StatusIDs, objID)
+	}
+
+	query.From, query.To, err = timeRange(q.From, q.To)
+	if err != nil {
+		return query, err
+	}
+
+	return query, nil
 }
 
-func (s *OrderStatusService) GetAllOrderStatuses() ([]dto.OrderStatusDTO, error) {
-	statuses, err := s.repo.FindAll(context.Background())
+// GetHistory devuelve el historial de una orden (por defecto en orden cronológico)
+func (s *OrderStatusService) GetHistory(ctx context.Context, orderStatusID string, q dto.StatusHistoryQuery) (dto.StatusHistoryPage, error) {
+	objID, err := primitive.ObjectIDFromHex(orderStatusID)
 	if err != nil {
-		return nil, err
+		return dto.StatusHistoryPage{}, NewError(ErrInvalidQuery, "order_status.invalid_id")
 	}
-	return mapper.ToOrderStatusDTOs(statuses), nil
+	q.OrderID = ""
+	query, err := toHistoryQuery(q, true)
+	if err != nil {
+		return dto.StatusHistoryPage{}, err
+	}
+	query.OrderStatusID = &objID
+	return s.findHistory(ctx, query)
 }
 
-func (s *OrderStatusService) GetStatusesByUser(userID string) ([]dto.OrderStatusDTO, error) {
-	statuses, err := s.repo.FindByUser(context.Background(), userID)
+// GetHistoryFeed devuelve el historial de todas las órdenes (por defecto lo más reciente primero)
+func (s *OrderStatusService) GetHistoryFeed(ctx context.Context, q dto.StatusHistoryQuery) (dto.StatusHistoryPage, error) {
+	query, err := toHistoryQuery(q, false)
 	if err != nil {
-		return nil, err
+		return dto.StatusHistoryPage{}, err
 	}
-	return mapper.ToOrderStatusDTOs(statuses), nil
+	return s.findHistory(ctx, query)
 }
 
-func (s *OrderStatusService) GetByStatus(status string) ([]dto.OrderStatusDTO, error) {
-	statuses, err := s.repo.FindByStatus(context.Background(), status)
+func (s *OrderStatusService) findHistory(ctx context.Context, query repository.HistoryQuery) (dto.StatusHistoryPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	records, next, err := s.repo.FindHistory(ctx, query)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return dto.StatusHistoryPage{}, NewError(ErrInvalidQuery, "query.invalid_cursor")
+	}
 	if err != nil {
-		return nil, err
+		return dto.StatusHistoryPage{}, err
 	}
-	return mapper.ToOrderStatusDTOs(statuses), nil
+	return dto.StatusHistoryPage{Items: mapper.ToStatusHistoryItemDTOs(records), NextCursor: next}, nil
 }
 
-func (s *OrderStatusService) GetByStatusID(statusID string) ([]dto.OrderStatusDTO, error) {
-	objID, err := primitive.ObjectIDFromHex(statusID)
+// Valida los parámetros de una consulta de historial
+func toHistoryQuery(q dto.StatusHistoryQuery, ascendingByDefault bool) (repository.HistoryQuery, error) {
+	query := repository.HistoryQuery{
+		OrderID:   q.OrderID,
+		Role:      q.Role,
+		ActorID:   q.UserID,
+		Status:    q.Status,
+		Ascending: ascendingByDefault,
+		Cursor:    q.Cursor,
+	}
+
+	switch q.Order {
+	case "":
+	case "asc":
+		query.Ascending = true
+	case "desc":
+		query.Ascending = false
+	default:
+		return query, NewError(ErrInvalidQuery, "query.invalid_order")
+	}
+
+	limit, err := pageLimit(q.Limit)
 	if err != nil {
-		return nil, fmt.Errorf("invalid status id")
+		return query, err
 	}
+	query.Limit = limit
 
-	statuses, err := s.repo.FindByStatusID(context.Background(), objID)
+	query.From, query.To, err = timeRange(q.From, q.To)
 	if err != nil {
-		return nil, err
+		return query, err
 	}
+	return query, nil
+}
 
-	return mapper.ToOrderStatusDTOs(statuses), nil
+// Valida el tamaño de página pedido (0 = por defecto)
+func pageLimit(limit int) (int64, error) {
+	if limit < 0 || limit > maxPageSize {
+		return 0, NewError(ErrInvalidQuery, "query.invalid_limit", maxPageSize)
+	}
+	if limit == 0 {
+		return defaultPageSize, nil
+	}
+	return int64(limit), nil
+}
+
+// Interpreta un rango de fechas RFC3339 (ambos extremos opcionales)
+func timeRange(fromStr string, toStr string) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if fromStr != "" {
+		t, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return nil, nil, NewError(ErrInvalidQuery, "query.invalid_from")
+		}
+		from = &t
+	}
+	if toStr != "" {
+		t, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return nil, nil, NewError(ErrInvalidQuery, "query.invalid_to")
+		}
+		to = &t
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, NewError(ErrInvalidQuery, "query.invalid_range")
+	}
+	return from, to, nil
 }
 
-func (s *OrderStatusService) IsCancelStatus(id string) (bool, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+// Localize reemplaza el nombre del estado por su etiqueta en el idioma pedido
+func (s *OrderStatusService) Localize(ctx context.Context, dtos []dto.OrderStatusDTO, locale string) []dto.OrderStatusDTO {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	statuses, err := s.catalogRepo.GetAll(ctx)
 	if err != nil {
-		return false, fmt.Errorf("invalid status id")
+		// sin catálogo se devuelve el nombre desnormalizado
+		return dtos
+	}
+	byID := make(map[string]model.StatusCatalog, len(statuses))
+	for _, st := range statuses {
+		byID[st.ID.Hex()] = st
+	}
+	for i := range dtos {
+		if st, ok := byID[dtos[i].StatusID]; ok {
+			mapper.LocalizeStatus(&dtos[i], st, locale, i18n.DefaultLocale)
+		}
 	}
+	return dtos
+}
+
+// LocalizeOne es la variante de Localize para un único DTO
+func (s *OrderStatusService) LocalizeOne(ctx context.Context, d dto.OrderStatusDTO, locale string) dto.OrderStatusDTO {
+	return s.Localize(ctx, []dto.OrderStatusDTO{d}, locale)[0]
+}
 
-	st, err := s.catalogRepo.FindByID(context.Background(), objID)
+// LocalizeEvent reemplaza el nombre del estado de un evento por su etiqueta en el idioma pedido
+func (s *OrderStatusService) LocalizeEvent(ctx context.Context, payload model.OrderStatusEventPayload, locale string) model.OrderStatusEventPayload {
+	statusID, err := primitive.ObjectIDFromHex(payload.StatusID)
+	if err != nil {
+		return payload
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	st, err := s.catalogRepo.FindByID(ctx, statusID)
 	if err != nil {
-		return false, err
+		return payload
 	}
+	payload.Status = st.Label(locale, i18n.DefaultLocale)
+	if payload.Entry != nil {
+		// copia: el mismo payload se reparte entre todos los suscriptores
+		entry := *payload.Entry
+		entry.Status = payload.Status
+		payload.Entry = &entry
+	}
+	return payload
+}
 
-	return st.Name == "Cancelado", nil
+// Busca la transición saliente de un estado hacia el estado destino
+func findTransition(from model.StatusCatalog, toID primitive.ObjectID) (model.StatusTransition, bool) {
+	for _, t := range from.Transitions {
+		if t.ToID == toID {
+			return t, true
+		}
+	}
+	return model.StatusTransition{}, false
 }
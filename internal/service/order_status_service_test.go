@@ -0,0 +1,238 @@
+// order_status_service_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"order-status-service/internal/config"
+	"order-status-service/internal/model"
+	"order-status-service/internal/policy"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Catálogo en memoria, con la misma semántica de búsqueda que repository.CatalogRepository
+type memoryCatalog struct {
+	statuses []model.StatusCatalog
+}
+
+func (m *memoryCatalog) GetAll(ctx context.Context) ([]model.StatusCatalog, error) {
+	return append([]model.StatusCatalog{}, m.statuses...), nil
+}
+
+func (m *memoryCatalog) ExistsByID(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	_, err := m.FindByID(ctx, id)
+	return err == nil, nil
+}
+
+func (m *memoryCatalog) ExistsByName(ctx context.Context, name string) (bool, error) {
+	_, err := m.FindByName(ctx, name)
+	return err == nil, nil
+}
+
+func (m *memoryCatalog) FindByID(ctx context.Context, id primitive.ObjectID) (model.StatusCatalog, error) {
+	for _, st := range m.statuses {
+		if st.ID == id {
+			return st, nil
+		}
+	}
+	return model.StatusCatalog{}, mongo.ErrNoDocuments
+}
+
+func (m *memoryCatalog) FindByName(ctx context.Context, name string) (model.StatusCatalog, error) {
+	for _, st := range m.statuses {
+		if st.Name == name {
+			return st, nil
+		}
+	}
+	return model.StatusCatalog{}, mongo.ErrNoDocuments
+}
+
+func (m *memoryCatalog) FindInitial(ctx context.Context) (model.StatusCatalog, error) {
+	for _, st := range m.statuses {
+		if st.IsInitial && !st.Archived {
+			return st, nil
+		}
+	}
+	return model.StatusCatalog{}, mongo.ErrNoDocuments
+}
+
+// Estados de órdenes en memoria. Los listados y el historial no se usan en estos tests:
+// llamarlos entra en la interfaz nil y hace fallar el test.
+type memoryOrderStatuses struct {
+	OrderStatusStore
+	mu   sync.Mutex
+	docs map[primitive.ObjectID]model.OrderStatus
+}
+
+func (m *memoryOrderStatuses) FindByID(ctx context.Context, id primitive.ObjectID) (model.OrderStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.docs[id]
+	if !ok {
+		return model.OrderStatus{}, mongo.ErrNoDocuments
+	}
+	return doc, nil
+}
+
+func (m *memoryOrderStatuses) UpdateStatusWithEntry(ctx context.Context, current model.OrderStatus, statusID primitive.ObjectID, statusName string, entry model.StatusEntry) (model.OrderStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.docs[current.ID]
+	if !ok {
+		return model.OrderStatus{}, mongo.ErrNoDocuments
+	}
+	if doc.Version != current.Version {
+		return model.OrderStatus{}, errors.New("version conflict")
+	}
+	doc.StatusID, doc.Status = statusID, statusName
+	doc.History = append(doc.History, entry)
+	doc.Version++
+	m.docs[doc.ID] = doc
+	return doc, nil
+}
+
+// Servicio con el catálogo por defecto (el mismo que se siembra al arrancar) y una orden
+// del usuario "owner", asignada a los vendedores "seller-1" y "owner" (un vendedor que compra en su
+// propia tienda), en el estado from
+func newTestOrderStatusService(t *testing.T, from string) (*OrderStatusService, *memoryOrderStatuses, *memoryCatalog, model.OrderStatus) {
+	t.Helper()
+	catalog := &memoryCatalog{statuses: defaultCatalog()}
+	current, err := catalog.FindByName(context.Background(), from)
+	if err != nil {
+		t.Fatalf("status %q not in the default catalog", from)
+	}
+	doc := model.OrderStatus{
+		ID:        primitive.NewObjectID(),
+		OrderID:   "order-1",
+		UserID:    "owner",
+		SellerIDs: []string{"seller-1", "owner"},
+		StatusID:  current.ID,
+		Status:    current.Name,
+		Version:   1,
+	}
+	store := &memoryOrderStatuses{docs: map[primitive.ObjectID]model.OrderStatus{doc.ID: doc}}
+	svc := NewOrderStatusService(store, catalog, NewStatusBroker(), nil, config.TimeoutsConfig{Read: time.Second, Write: time.Second})
+	return svc, store, catalog, doc
+}
+
+func TestChangeStatusWithDefaultCatalog(t *testing.T) {
+	// Comportamiento esperado del catálogo por defecto: el vendedor (y el admin) hacen avanzar
+	// o rechazan la orden, el dueño solo puede cancelarla antes del envío
+	sellerMoves := []string{
+		"Pendiente -> En preparación", "Pendiente -> Enviado", "Pendiente -> Rechazado",
+		"En preparación -> Enviado", "En preparación -> Rechazado",
+		"Enviado -> Entregado",
+	}
+	ownerMoves := []string{"Pendiente -> Cancelado", "En preparación -> Cancelado"}
+	terminal := map[string]bool{"Entregado": true, "Cancelado": true, "Rechazado": true}
+
+	// transiciones permitidas -> rol con que queda registrada
+	moves := func(role policy.Role, lists ...[]string) map[string]policy.Role {
+		res := map[string]policy.Role{}
+		for _, list := range lists {
+			for _, m := range list {
+				res[m] = role
+			}
+		}
+		return res
+	}
+	merge := func(maps ...map[string]policy.Role) map[string]policy.Role {
+		res := map[string]policy.Role{}
+		for _, m := range maps {
+			for k, v := range m {
+				res[k] = v
+			}
+		}
+		return res
+	}
+
+	actors := []struct {
+		name    string
+		actor   policy.Actor
+		visible bool
+		allowed map[string]policy.Role
+	}{
+		{"admin", policy.Actor{ID: "admin-1", Role: policy.RoleAdmin}, true, moves(policy.RoleAdmin, sellerMoves)},
+		{"system", policy.Actor{ID: "orders", Role: policy.RoleSystem}, true, moves(policy.RoleSystem, sellerMoves, ownerMoves)},
+		{"assigned seller", policy.Actor{ID: "seller-1", Role: policy.RoleSeller}, true, moves(policy.RoleSeller, sellerMoves)},
+		{"owner", policy.Actor{ID: "owner", Role: policy.RoleClient}, true, moves(policy.RoleClient, ownerMoves)},
+		{"assigned seller owning the order", policy.Actor{ID: "owner", Role: policy.RoleSeller}, true,
+			merge(moves(policy.RoleSeller, sellerMoves), moves(policy.RoleClient, ownerMoves))},
+		{"admin owning the order", policy.Actor{ID: "owner", Role: policy.RoleAdmin}, true,
+			merge(moves(policy.RoleAdmin, sellerMoves), moves(policy.RoleClient, ownerMoves))},
+		{"unrelated seller", policy.Actor{ID: "seller-2", Role: policy.RoleSeller}, false, nil},
+		{"client on someone else's order", policy.Actor{ID: "other", Role: policy.RoleClient}, false, nil},
+	}
+
+	edges := merge(moves("", sellerMoves), moves("", ownerMoves))
+	for _, from := range defaultStatuses {
+		for _, to := range defaultStatuses {
+			if from.Name == to.Name {
+				continue
+			}
+			move := from.Name + " -> " + to.Name
+			for _, a := range actors {
+				t.Run(move+"/"+a.name, func(t *testing.T) {
+					svc, store, catalog, doc := newTestOrderStatusService(t, from.Name)
+					target, _ := catalog.FindByName(context.Background(), to.Name)
+
+					result, err := svc.ChangeStatus(context.Background(), doc.ID.Hex(), target.ID.Hex(), a.actor, "", nil)
+
+					var want error
+					role, allowed := a.allowed[move]
+					_, edge := edges[move]
+					switch {
+					case !a.visible:
+						want = ErrNotFound
+					case terminal[from.Name]:
+						want = ErrTerminalState
+					case !edge:
+						want = ErrTransitionNotAllowed
+					case !allowed:
+						want = ErrForbiddenTransition
+					}
+					if want != nil {
+						if !errors.Is(err, want) {
+							t.Fatalf("ChangeStatus() = %v, want %v", err, want)
+						}
+						if after, _ := store.FindByID(context.Background(), doc.ID); after.Version != doc.Version {
+							t.Errorf("order changed after a rejected transition (version %d, want %d)", after.Version, doc.Version)
+						}
+						return
+					}
+
+					if err != nil {
+						t.Fatalf("ChangeStatus() = %v, want nil", err)
+					}
+					if result.Status != to.Name {
+						t.Errorf("status = %q, want %q", result.Status, to.Name)
+					}
+					after, _ := store.FindByID(context.Background(), doc.ID)
+					if len(after.History) != 1 || after.History[0].Role != string(role) {
+						t.Errorf("history = %+v, want one entry with role %q", after.History, role)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestChangeStatusToArchivedStatus(t *testing.T) {
+	svc, _, catalog, doc := newTestOrderStatusService(t, "Pendiente")
+	for i := range catalog.statuses {
+		if catalog.statuses[i].Name == "En preparación" {
+			catalog.statuses[i].Archived = true
+		}
+	}
+	target, _ := catalog.FindByName(context.Background(), "En preparación")
+
+	_, err := svc.ChangeStatus(context.Background(), doc.ID.Hex(), target.ID.Hex(), policy.Actor{ID: "seller-1", Role: policy.RoleSeller}, "", nil)
+	if !errors.Is(err, ErrStatusArchived) {
+		t.Errorf("ChangeStatus() = %v, want ErrStatusArchived", err)
+	}
+}
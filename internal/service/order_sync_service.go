@@ -0,0 +1,206 @@
+// order_sync_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"order-status-service/internal/config"
+	"order-status-service/internal/model"
+	"order-status-service/internal/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Acción del servicio de órdenes según la categoría del estado terminal alcanzado
+var orderSyncActions = map[model.StatusCategory]model.OrderSyncAction{
+	model.CategoryCancelled: model.OrderSyncCancel,
+	model.CategoryRejected:  model.OrderSyncRefundNeeded,
+	model.CategoryDelivered: model.OrderSyncFulfilled,
+}
+
+// OrderSyncService avisa al microservicio de órdenes cuando una orden llega a un estado terminal,
+// para que libere stock y pagos. Los avisos se crean desde el outbox (ver Publish), se guardan en
+// Mongo y los envía un worker con reintentos, por lo que sobreviven a reinicios y a caídas del
+// servicio de órdenes.
+type OrderSyncService struct {
+	repo        *repository.OrderSyncRepository
+	catalogRepo *repository.CatalogRepository
+	orders      *OrdersClient
+	wake        chan struct{}
+	timeouts    config.TimeoutsConfig
+
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+func NewOrderSyncService(repo *repository.OrderSyncRepository, catalogRepo *repository.CatalogRepository, orders *OrdersClient, cfg config.WorkerConfig, timeouts config.TimeoutsConfig) *OrderSyncService {
+	return &OrderSyncService{
+		repo:         repo,
+		catalogRepo:  catalogRepo,
+		orders:       orders,
+		wake:         make(chan struct{}, 1),
+		timeouts:     timeouts,
+		PollInterval: cfg.PollInterval,
+		Lease:        cfg.Lease,
+		MaxAttempts:  cfg.MaxAttempts,
+		BaseBackoff:  cfg.BaseBackoff,
+		MaxBackoff:   cfg.MaxBackoff,
+	}
+}
+
+// Publish registra el aviso si el evento es un cambio a un estado terminal cuya categoría tiene
+// una acción en el servicio de órdenes. Lo llama el relay del outbox con cada evento confirmado,
+// así el aviso no se pierde si el proceso cae justo después del cambio; si devuelve error, el
+// relay reintenta el evento y el aviso ya creado para él no se duplica.
+func (s *OrderSyncService) Publish(ctx context.Context, event model.OutboxEvent) error {
+	if event.Type != model.EventStatusChanged {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	payload := event.Payload
+	statusID, err := primitive.ObjectIDFromHex(payload.StatusID)
+	if err != nil {
+		return nil
+	}
+	status, err := s.catalogRepo.FindByID(ctx, statusID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		log.Printf("⚠️ Orders sync: el estado %s de la orden %s ya no existe en el catálogo", payload.StatusID, payload.OrderID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("orders sync: finding status: %w", err)
+	}
+	action, ok := orderSyncActions[status.Category]
+	if !status.IsTerminal || !ok {
+		return nil
+	}
+
+	now := time.Now()
+	sync := model.OrderSync{
+		ID:            primitive.NewObjectID(),
+		EventID:       event.ID,
+		OrderID:       payload.OrderID,
+		Action:        action,
+		Payload:       payload,
+		Status:        model.DeliveryPending,
+		Attempts:      []model.WebhookAttempt{},
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.Insert(ctx, sync); err != nil {
+		return fmt.Errorf("orders sync: storing sync for order %s: %w", payload.OrderID, err)
+	}
+	s.notify()
+	return nil
+}
+
+// GetSyncs devuelve los últimos avisos en el estado pedido (por defecto, los fallidos)
+func (s *OrderSyncService) GetSyncs(ctx context.Context, status string) ([]model.OrderSync, error) {
+	switch model.DeliveryStatus(status) {
+	case "":
+		status = string(model.DeliveryFailed)
+	case model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed:
+	default:
+		return nil, NewError(ErrInvalidQuery, "order_sync.invalid_status")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.repo.FindByStatus(ctx, model.DeliveryStatus(status), 100)
+}
+
+// Retry vuelve a encolar un aviso (p. ej. uno fallido) para enviarlo de inmediato
+func (s *OrderSyncService) Retry(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return NewError(ErrInvalidInput, "order_sync.invalid_id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+	if err := s.repo.Reset(ctx, objID); err != nil {
+		return notFound(err, "order_sync.not_found")
+	}
+	s.notify()
+	return nil
+}
+
+// Despierta al worker sin bloquear si ya tiene un aviso pendiente
+func (s *OrderSyncService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run envía los avisos pendientes hasta que se cancele el contexto
+func (s *OrderSyncService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *OrderSyncService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		sync, err := s.repo.ClaimNext(ctx, s.Lease)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return
+		}
+		if err != nil {
+			log.Printf("⚠️ Orders sync: error al leer avisos pendientes: %v", err)
+			return
+		}
+		s.attempt(ctx, sync)
+	}
+}
+
+// Realiza un intento de envío y registra su resultado
+func (s *OrderSyncService) attempt(ctx context.Context, sync model.OrderSync) {
+	attempt := s.orders.SendSync(ctx, sync)
+
+	status := model.DeliverySucceeded
+	next := time.Now()
+	if attempt.Error != "" {
+		retries := sync.Retries + 1
+		if retries >= s.MaxAttempts {
+			status = model.DeliveryFailed
+			log.Printf("❌ Orders sync: aviso %s (%s) de la orden %s fallido tras %d intentos", sync.ID.Hex(), sync.Action, sync.OrderID, retries)
+		} else {
+			status = model.DeliveryPending
+			next = next.Add(s.backoff(retries))
+		}
+	}
+
+	if err := s.repo.RecordAttempt(ctx, sync.ID, attempt, status, next); err != nil {
+		log.Printf("⚠️ Orders sync: error al registrar intento de %s: %v", sync.ID.Hex(), err)
+	}
+}
+
+// Espera antes del próximo intento: BaseBackoff * 2^(intentos-1), acotado por MaxBackoff
+func (s *OrderSyncService) backoff(retries int) time.Duration {
+	d := s.BaseBackoff
+	for i := 1; i < retries; i++ {
+		d *= 2
+		if d >= s.MaxBackoff {
+			return s.MaxBackoff
+		}
+	}
+	return d
+}
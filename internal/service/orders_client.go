@@ -0,0 +1,251 @@
+// orders_client.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"order-status-service/internal/config"
+	"order-status-service/internal/dto"
+	"order-status-service/internal/model"
+	"strings"
+	"time"
+)
+
+var (
+	// El servicio de órdenes no conoce la orden
+	ErrOrderNotFound = errors.New("order not found")
+	// La orden existe pero no coincide con los datos recibidos (usuario o envío)
+	ErrOrderMismatch = errors.New("order does not match the orders service")
+	// El servicio de órdenes no respondió (red, 429/5xx o circuito abierto)
+	ErrOrdersUnavailable = errors.New("orders service unavailable")
+	// El servicio de órdenes rechazó la consulta con un 4xx distinto de 404 y 429 (p. ej.
+	// credenciales o id inválidos): reintentar no sirve y la orden no puede darse por válida
+	ErrOrdersRejected = errors.New("orders service rejected the request")
+)
+
+// Orden según el microservicio de órdenes (solo los campos que se validan)
+type Order struct {
+	ID       string         `json:"id"`
+	UserID   string         `json:"user_id"`
+	Shipping *OrderShipping `json:"shipping,omitempty"`
+}
+
+type OrderShipping struct {
+	AddressLine1 string `json:"address_line1"`
+	City         string `json:"city"`
+	Country      string `json:"country"`
+	Zipcode      string `json:"zipcode"`
+}
+
+// OrdersClient habla con el microservicio de órdenes: consulta órdenes con timeout por intento,
+// reintentos con backoff exponencial y un circuit breaker, y envía los avisos de OrderSyncService
+type OrdersClient struct {
+	baseURL      string
+	client       *http.Client
+	strict       bool
+	maxAttempts  int
+	retryBackoff time.Duration
+	breaker      *circuitBreaker
+	cooldown     time.Duration
+}
+
+func NewOrdersClient(cfg config.OrdersConfig) *OrdersClient {
+	return &OrdersClient{
+		baseURL:      strings.TrimSuffix(cfg.URL, "/"),
+		client:       &http.Client{Timeout: cfg.Timeout},
+		strict:       cfg.Validation == config.OrdersValidationStrict,
+		maxAttempts:  cfg.MaxAttempts,
+		retryBackoff: cfg.RetryBackoff,
+		breaker:      newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		cooldown:     cfg.BreakerCooldown,
+	}
+}
+
+// VerifyOrder comprueba contra el servicio de órdenes que la orden exista, que sea del usuario
+// y que los datos de envío coincidan. Si el servicio no está disponible, en modo strict se
+// rechaza la petición y en modo lenient la orden se acepta sin validar. Si el servicio rechaza
+// la consulta se rechaza la petición en ambos modos.
+func (c *OrdersClient) VerifyOrder(ctx context.Context, req dto.CreateOrderStatusRequest) error {
+	order, err := c.GetOrder(ctx, req.OrderID)
+	switch {
+	case errors.Is(err, ErrOrderNotFound):
+		return NewError(ErrOrderNotFound, "orders.order_not_found", req.OrderID)
+	case errors.Is(err, ErrOrdersRejected):
+		log.Printf("⚠️ Order %s rejected: %v", req.OrderID, err)
+		return NewError(ErrOrdersRejected, "orders.rejected", req.OrderID)
+	case errors.Is(err, ErrOrdersUnavailable):
+		if c.strict {
+			log.Printf("⚠️ Order %s rejected: %v", req.OrderID, err)
+			return NewError(ErrUnavailable, "orders.unavailable")
+		}
+		log.Printf("⚠️ Order %s accepted without validation: %v", req.OrderID, err)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if order.UserID != req.UserID {
+		return NewError(ErrOrderMismatch, "orders.user_mismatch", req.OrderID)
+	}
+	if field := shippingMismatch(order.Shipping, req.Shipping); field != "" {
+		return NewError(ErrOrderMismatch, "orders.shipping_mismatch", field)
+	}
+	return nil
+}
+
+// GetOrder busca la orden con GET <orders.url>/orders/{id}. Las fallas del servicio se devuelven
+// envueltas en ErrOrdersUnavailable y cuentan para el circuit breaker; una orden inexistente o
+// una consulta rechazada (ErrOrdersRejected) no, porque el servicio respondió.
+func (c *OrdersClient) GetOrder(ctx context.Context, orderID string) (*Order, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrdersUnavailable, err)
+	}
+
+	order, err := c.fetchWithRetries(ctx, orderID)
+	switch {
+	case err == nil || errors.Is(err, ErrOrderNotFound) || errors.Is(err, ErrOrdersRejected):
+		c.breaker.success()
+	case ctx.Err() != nil:
+		// la petición se canceló: no dice nada sobre el servicio de órdenes
+		c.breaker.cancel()
+	default:
+		if c.breaker.failure() {
+			log.Printf("⚠️ Orders service circuit breaker opened for %s: %v", c.cooldown, err)
+		}
+	}
+	return order, err
+}
+
+func (c *OrdersClient) fetchWithRetries(ctx context.Context, orderID string) (*Order, error) {
+	backoff := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		order, retry, err := c.fetch(ctx, orderID)
+		if err == nil || !retry || attempt >= c.maxAttempts {
+			return order, err
+		}
+		log.Printf("⚠️ Orders service request failed (attempt %d/%d): %v", attempt, c.maxAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Un intento de GET /orders/{id}; retry indica si el error es transitorio
+func (c *OrdersClient) fetch(ctx context.Context, orderID string) (order *Order, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/orders/%s", c.baseURL, url.PathEscape(orderID)), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		return nil, true, fmt.Errorf("%w: %v", ErrOrdersUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, ErrOrderNotFound
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("%w: status %d", ErrOrdersUnavailable, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return nil, false, fmt.Errorf("%w: status %d", ErrOrdersRejected, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("%w: status %d", ErrOrdersUnavailable, resp.StatusCode)
+	}
+
+	order = &Order{}
+	if err := json.NewDecoder(resp.Body).Decode(order); err != nil {
+		return nil, false, fmt.Errorf("%w: invalid response: %v", ErrOrdersUnavailable, err)
+	}
+	return order, false, nil
+}
+
+// SendSync avisa al servicio de órdenes que la orden llegó a un estado terminal con
+// POST <orders.url>/orders/{id}/status-sync. El id del aviso viaja como Idempotency-Key para que
+// el servicio de órdenes descarte los reintentos. Hace un solo intento y no pasa por el circuit
+// breaker: los reintentos los programa el worker de OrderSyncService.
+func (c *OrdersClient) SendSync(ctx context.Context, sync model.OrderSync) model.WebhookAttempt {
+	start := time.Now()
+	attempt := model.WebhookAttempt{At: start}
+
+	// los avisos salen de cambios de estado, que siempre traen su entrada de historial
+	var entry model.StatusEntry
+	if sync.Payload.Entry != nil {
+		entry = *sync.Payload.Entry
+	}
+	body, err := json.Marshal(map[string]any{
+		"id":              sync.ID.Hex(),
+		"action":          sync.Action,
+		"order_status_id": sync.Payload.OrderStatusID,
+		"status_id":       sync.Payload.StatusID,
+		"status":          sync.Payload.Status,
+		"previous_status": sync.Payload.PreviousStatus,
+		"reason":          entry.Reason,
+		"changed_at":      entry.At,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/orders/%s/status-sync", c.baseURL, url.PathEscape(sync.OrderID)), bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", sync.ID.Hex())
+
+	resp, err := c.client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// Devuelve el primer campo del envío que no coincide con la orden. Solo se comparan los campos
+// que la orden informa, sin distinguir mayúsculas ni espacios alrededor.
+func shippingMismatch(order *OrderShipping, shipping dto.ShippingDTO) string {
+	if order == nil {
+		return ""
+	}
+	fields := []struct {
+		name, want, got string
+	}{
+		{"address_line1", order.AddressLine1, shipping.AddressLine1},
+		{"city", order.City, shipping.City},
+		{"country", order.Country, shipping.Country},
+		{"zipcode", order.Zipcode, shipping.Zipcode},
+	}
+	for _, f := range fields {
+		want := strings.TrimSpace(f.want)
+		if want != "" && !strings.EqualFold(want, strings.TrimSpace(f.got)) {
+			return f.name
+		}
+	}
+	return ""
+}
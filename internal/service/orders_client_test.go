@@ -0,0 +1,231 @@
+// orders_client_test.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"order-status-service/internal/config"
+	"order-status-service/internal/dto"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testBreakerCooldown = 50 * time.Millisecond
+
+// Servicio de órdenes falso: responde la orden con 200 o solo el código configurado, y cuenta
+// las peticiones que le llegan
+type fakeOrders struct {
+	status atomic.Int32
+	calls  atomic.Int32
+	order  Order
+}
+
+func newFakeOrders(t *testing.T, status int) (*fakeOrders, *httptest.Server) {
+	fake := &fakeOrders{order: Order{
+		ID:       "order-1",
+		UserID:   "user-1",
+		Shipping: &OrderShipping{AddressLine1: "Av. Siempreviva 742", City: "Córdoba", Country: "AR"},
+	}}
+	fake.status.Store(int32(status))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.calls.Add(1)
+		if r.URL.Path != "/orders/order-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		status := int(fake.status.Load())
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fake.order)
+	}))
+	t.Cleanup(srv.Close)
+	return fake, srv
+}
+
+func newTestOrdersClient(url, validation string) *OrdersClient {
+	return NewOrdersClient(config.OrdersConfig{
+		URL:              url,
+		Validation:       validation,
+		Timeout:          time.Second,
+		MaxAttempts:      3,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 2,
+		BreakerCooldown:  testBreakerCooldown,
+	})
+}
+
+func verifyRequest() dto.CreateOrderStatusRequest {
+	return dto.CreateOrderStatusRequest{
+		OrderID: "order-1",
+		UserID:  "user-1",
+		Shipping: dto.ShippingDTO{
+			AddressLine1: "Av. Siempreviva 742",
+			City:         " córdoba ",
+			Country:      "AR",
+		},
+	}
+}
+
+func TestVerifyOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		edit    func(req *dto.CreateOrderStatusRequest)
+		wantErr error
+	}{
+		{"match", http.StatusOK, func(req *dto.CreateOrderStatusRequest) {}, nil},
+		{"other user", http.StatusOK, func(req *dto.CreateOrderStatusRequest) { req.UserID = "user-2" }, ErrOrderMismatch},
+		{"other city", http.StatusOK, func(req *dto.CreateOrderStatusRequest) { req.Shipping.City = "Rosario" }, ErrOrderMismatch},
+		{"zipcode not informed by the order", http.StatusOK, func(req *dto.CreateOrderStatusRequest) { req.Shipping.Zipcode = "5000" }, nil},
+		{"unknown order", http.StatusNotFound, func(req *dto.CreateOrderStatusRequest) {}, ErrOrderNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, srv := newFakeOrders(t, tt.status)
+			req := verifyRequest()
+			tt.edit(&req)
+
+			err := newTestOrdersClient(srv.URL, config.OrdersValidationStrict).VerifyOrder(context.Background(), req)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyOrder() = %v, want %v", err, tt.wantErr)
+			}
+			if calls := fake.calls.Load(); calls != 1 {
+				t.Errorf("orders service got %d requests, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestVerifyOrderValidationModes(t *testing.T) {
+	tests := []struct {
+		validation string
+		status     int
+		wantErr    error
+		wantCalls  int32
+	}{
+		// el servicio no responde: strict rechaza, lenient acepta sin validar
+		{config.OrdersValidationStrict, http.StatusServiceUnavailable, ErrUnavailable, 3},
+		{config.OrdersValidationLenient, http.StatusServiceUnavailable, nil, 3},
+		// el servicio rechaza la consulta: no se reintenta y nunca se acepta
+		{config.OrdersValidationStrict, http.StatusBadRequest, ErrOrdersRejected, 1},
+		{config.OrdersValidationLenient, http.StatusBadRequest, ErrOrdersRejected, 1},
+		{config.OrdersValidationLenient, http.StatusForbidden, ErrOrdersRejected, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.validation+"/"+http.StatusText(tt.status), func(t *testing.T) {
+			fake, srv := newFakeOrders(t, tt.status)
+
+			err := newTestOrdersClient(srv.URL, tt.validation).VerifyOrder(context.Background(), verifyRequest())
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyOrder() = %v, want %v", err, tt.wantErr)
+			}
+			if calls := fake.calls.Load(); calls != tt.wantCalls {
+				t.Errorf("orders service got %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetOrderRetriesAndOpensBreaker(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			fake, srv := newFakeOrders(t, status)
+			client := newTestOrdersClient(srv.URL, config.OrdersValidationStrict)
+
+			// cada consulta hace max_attempts intentos; con threshold 2 la segunda abre el circuito
+			for i, wantCalls := range []int32{3, 6} {
+				if _, err := client.GetOrder(context.Background(), "order-1"); !errors.Is(err, ErrOrdersUnavailable) {
+					t.Fatalf("GetOrder() #%d = %v, want ErrOrdersUnavailable", i+1, err)
+				}
+				if calls := fake.calls.Load(); calls != wantCalls {
+					t.Fatalf("after GetOrder() #%d orders service got %d requests, want %d", i+1, calls, wantCalls)
+				}
+			}
+
+			// con el circuito abierto no se llama al servicio
+			if _, err := client.GetOrder(context.Background(), "order-1"); !errors.Is(err, ErrOrdersUnavailable) {
+				t.Fatalf("GetOrder() with the circuit open = %v, want ErrOrdersUnavailable", err)
+			}
+			if calls := fake.calls.Load(); calls != 6 {
+				t.Errorf("orders service got %d requests with the circuit open, want none", calls-6)
+			}
+		})
+	}
+}
+
+func TestGetOrderAnsweredErrorsDoNotOpenBreaker(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusBadRequest, http.StatusUnauthorized} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			fake, srv := newFakeOrders(t, status)
+			client := newTestOrdersClient(srv.URL, config.OrdersValidationStrict)
+
+			for i := 0; i < 4; i++ {
+				if _, err := client.GetOrder(context.Background(), "order-1"); err == nil {
+					t.Fatalf("GetOrder() #%d = nil, want an error", i+1)
+				}
+			}
+			if calls := fake.calls.Load(); calls != 4 {
+				t.Errorf("orders service got %d requests, want 4 (one per call, no retries, circuit closed)", calls)
+			}
+		})
+	}
+}
+
+func TestGetOrderHalfOpenProbe(t *testing.T) {
+	openBreaker := func(t *testing.T) (*fakeOrders, *OrdersClient) {
+		fake, srv := newFakeOrders(t, http.StatusInternalServerError)
+		client := newTestOrdersClient(srv.URL, config.OrdersValidationStrict)
+		for i := 0; i < 2; i++ {
+			client.GetOrder(context.Background(), "order-1")
+		}
+		fake.calls.Store(0)
+		return fake, client
+	}
+
+	t.Run("successful probe closes the circuit", func(t *testing.T) {
+		fake, client := openBreaker(t)
+		fake.status.Store(http.StatusOK)
+
+		// antes del cooldown el circuito sigue abierto aunque el servicio ya responda
+		if _, err := client.GetOrder(context.Background(), "order-1"); !errors.Is(err, ErrOrdersUnavailable) {
+			t.Fatalf("GetOrder() before the cooldown = %v, want ErrOrdersUnavailable", err)
+		}
+		time.Sleep(testBreakerCooldown)
+
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetOrder(context.Background(), "order-1"); err != nil {
+				t.Fatalf("GetOrder() #%d after the cooldown = %v, want nil", i+1, err)
+			}
+		}
+		if calls := fake.calls.Load(); calls != 2 {
+			t.Errorf("orders service got %d requests, want the probe and one more", calls)
+		}
+	})
+
+	t.Run("failed probe reopens the circuit", func(t *testing.T) {
+		fake, client := openBreaker(t)
+		time.Sleep(testBreakerCooldown)
+
+		if _, err := client.GetOrder(context.Background(), "order-1"); !errors.Is(err, ErrOrdersUnavailable) {
+			t.Fatalf("probe = %v, want ErrOrdersUnavailable", err)
+		}
+		probeCalls := fake.calls.Load()
+		if probeCalls != 3 {
+			t.Fatalf("probe made %d requests, want 3 (max_attempts)", probeCalls)
+		}
+		if _, err := client.GetOrder(context.Background(), "order-1"); !errors.Is(err, ErrOrdersUnavailable) {
+			t.Fatalf("GetOrder() after a failed probe = %v, want ErrOrdersUnavailable", err)
+		}
+		if calls := fake.calls.Load(); calls != probeCalls {
+			t.Errorf("orders service got %d requests after a failed probe, want none", calls-probeCalls)
+		}
+	})
+}
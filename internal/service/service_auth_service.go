@@ -0,0 +1,108 @@
+// service_auth_service.go
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"order-status-service/internal/config"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cabeceras de autenticación entre servicios
+const (
+	ServiceKeyHeader       = "X-Service-Key"
+	ServiceNameHeader      = "X-Service-Name"
+	ServiceTimestampHeader = "X-Service-Timestamp"
+	ServiceSignatureHeader = "X-Service-Signature" // "sha256=<hex>"
+)
+
+// Se devuelve cuando las credenciales del servicio llamador no son válidas
+var ErrInvalidServiceCredentials = errors.New("invalid service credentials")
+
+// Se devuelve cuando el timestamp de una petición firmada está fuera de la tolerancia (MaxClockSkew)
+var ErrServiceSignatureExpired = errors.New("service signature expired")
+
+// Identidad del servicio que hizo la llamada y cómo se autenticó
+type ServiceIdentity struct {
+	Name   string
+	Method string // api_key | hmac | mtls
+}
+
+// ServiceAuthService autentica a otros microservicios (p. ej. el de órdenes)
+type ServiceAuthService struct {
+	apiKeys      map[string]string
+	hmacSecrets  map[string]string
+	mtlsNames    []string
+	maxClockSkew time.Duration
+}
+
+func NewServiceAuthService(cfg config.ServiceAuthConfig) *ServiceAuthService {
+	return &ServiceAuthService{
+		apiKeys:      cfg.APIKeys,
+		hmacSecrets:  cfg.HMACSecrets,
+		mtlsNames:    cfg.MTLSNames,
+		maxClockSkew: cfg.MaxClockSkew,
+	}
+}
+
+// Enabled indica si hay al menos un servicio configurado
+func (s *ServiceAuthService) Enabled() bool {
+	return len(s.apiKeys) > 0 || len(s.hmacSecrets) > 0 || len(s.mtlsNames) > 0
+}
+
+// AuthenticateAPIKey busca la API key en el registro de servicios
+func (s *ServiceAuthService) AuthenticateAPIKey(key string) (ServiceIdentity, error) {
+	for name, expected := range s.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(expected)) == 1 {
+			return ServiceIdentity{Name: name, Method: "api_key"}, nil
+		}
+	}
+	return ServiceIdentity{}, ErrInvalidServiceCredentials
+}
+
+// VerifySignature valida la firma HMAC de la petición y que el timestamp no esté vencido
+func (s *ServiceAuthService) VerifySignature(name, timestamp, signature, method, path string, body []byte) (ServiceIdentity, error) {
+	secret, ok := s.hmacSecrets[name]
+	if !ok {
+		return ServiceIdentity{}, ErrInvalidServiceCredentials
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ServiceIdentity{}, ErrInvalidServiceCredentials
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > s.maxClockSkew || skew < -s.maxClockSkew {
+		return ServiceIdentity{}, ErrServiceSignatureExpired
+	}
+
+	expected := SignServiceRequest(secret, timestamp, method, path, body)
+	got := strings.TrimPrefix(signature, "sha256=")
+	if !hmac.Equal([]byte(got), []byte(expected)) {
+		return ServiceIdentity{}, ErrInvalidServiceCredentials
+	}
+	return ServiceIdentity{Name: name, Method: "hmac"}, nil
+}
+
+// AuthenticateCertificate acepta certificados cliente (ya verificados contra la CA en el handshake TLS)
+// cuyo CN esté en la lista de servicios permitidos
+func (s *ServiceAuthService) AuthenticateCertificate(cert *x509.Certificate) (ServiceIdentity, error) {
+	if cert == nil || !slices.Contains(s.mtlsNames, cert.Subject.CommonName) {
+		return ServiceIdentity{}, ErrInvalidServiceCredentials
+	}
+	return ServiceIdentity{Name: cert.Subject.CommonName, Method: "mtls"}, nil
+}
+
+// SignServiceRequest calcula la firma HMAC-SHA256 (hex) de "<timestamp>.<method>.<path>.<body>"
+func SignServiceRequest(secret, timestamp, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + method + "." + path + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,107 @@
+// status_broker.go
+package service
+
+import (
+	"order-status-service/internal/model"
+	"sync"
+)
+
+// StatusBroker reparte los cambios de estado de cada orden entre los clientes
+// suscriptos a ella (streams SSE). Vive en memoria del proceso: solo ve los cambios hechos
+// por esta instancia, así que con varias réplicas un stream no recibe los cambios que se
+// hagan en las otras (ver "Seguir en vivo el estado de una orden" en ShippingStatus.md).
+type StatusBroker struct {
+	mu     sync.RWMutex
+	subs   map[string]map[chan model.OrderStatusEventPayload]struct{}
+	closed bool
+
+	// Mensajes que se encolan por suscriptor antes de considerarlo lento
+	BufferSize int
+}
+
+func NewStatusBroker() *StatusBroker {
+	return &StatusBroker{
+		subs:       make(map[string]map[chan model.OrderStatusEventPayload]struct{}),
+		BufferSize: 16,
+	}
+}
+
+// Subscribe registra un suscriptor para la orden indicada. El canal se cierra
+// al desuscribirse, al cerrar el broker o si el suscriptor no consume a tiempo.
+func (b *StatusBroker) Subscribe(orderStatusID string) (<-chan model.OrderStatusEventPayload, func()) {
+	ch := make(chan model.OrderStatusEventPayload, b.BufferSize)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subs[orderStatusID] == nil {
+		b.subs[orderStatusID] = make(map[chan model.OrderStatusEventPayload]struct{})
+	}
+	b.subs[orderStatusID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.remove(orderStatusID, ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish envía el cambio a todos los suscriptores de la orden sin bloquear:
+// un suscriptor con el buffer lleno se desconecta para que vuelva a conectarse
+func (b *StatusBroker) Publish(orderStatusID string, payload model.OrderStatusEventPayload) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[orderStatusID] {
+		select {
+		case ch <- payload:
+		default:
+			b.remove(orderStatusID, ch)
+		}
+	}
+}
+
+// Subscribers devuelve la cantidad de suscriptores activos de una orden
+func (b *StatusBroker) Subscribers(orderStatusID string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[orderStatusID])
+}
+
+// Close desconecta a todos los suscriptores; los Subscribe posteriores reciben un canal cerrado
+func (b *StatusBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for id, chans := range b.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(b.subs, id)
+	}
+}
+
+// remove debe llamarse con el lock tomado
+func (b *StatusBroker) remove(orderStatusID string, ch chan model.OrderStatusEventPayload) {
+	chans, ok := b.subs[orderStatusID]
+	if !ok {
+		return
+	}
+	if _, ok := chans[ch]; !ok {
+		return
+	}
+	delete(chans, ch)
+	close(ch)
+	if len(chans) == 0 {
+		delete(b.subs, orderStatusID)
+	}
+}
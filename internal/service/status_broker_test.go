@@ -0,0 +1,142 @@
+// status_broker_test.go
+package service
+
+import (
+	"order-status-service/internal/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Lee del canal hasta que se cierre o pase el timeout
+func receiveAll(ch <-chan model.OrderStatusEventPayload, timeout time.Duration) (events []model.OrderStatusEventPayload, closed bool) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return events, true
+			}
+			events = append(events, e)
+		case <-deadline:
+			return events, false
+		}
+	}
+}
+
+func TestStatusBrokerFanOut(t *testing.T) {
+	const subscribers, published = 50, 10
+	b := NewStatusBroker()
+
+	chans := make([]<-chan model.OrderStatusEventPayload, subscribers)
+	for i := range chans {
+		chans[i], _ = b.Subscribe("order-1")
+	}
+	other, _ := b.Subscribe("order-2")
+	if n := b.Subscribers("order-1"); n != subscribers {
+		t.Fatalf("Subscribers() = %d, want %d", n, subscribers)
+	}
+
+	var wg sync.WaitGroup
+	received := make([][]model.OrderStatusEventPayload, subscribers)
+	for i, ch := range chans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			received[i], _ = receiveAll(ch, 200*time.Millisecond)
+		}()
+	}
+	for i := 0; i < published; i++ {
+		b.Publish("order-1", model.OrderStatusEventPayload{OrderStatusID: "order-1", Status: string(rune('A' + i))})
+	}
+	wg.Wait()
+
+	for i, events := range received {
+		if len(events) != published {
+			t.Fatalf("subscriber %d got %d events, want %d", i, len(events), published)
+		}
+		for j, e := range events {
+			if want := string(rune('A' + j)); e.Status != want {
+				t.Fatalf("subscriber %d event %d = %q, want %q (in order)", i, j, e.Status, want)
+			}
+		}
+	}
+	if events, _ := receiveAll(other, 10*time.Millisecond); len(events) != 0 {
+		t.Errorf("subscriber of another order got %d events, want none", len(events))
+	}
+}
+
+func TestStatusBrokerDropsSlowSubscriber(t *testing.T) {
+	b := NewStatusBroker()
+	b.BufferSize = 2
+
+	slow, _ := b.Subscribe("order-1")
+	fast, _ := b.Subscribe("order-1")
+
+	var wg sync.WaitGroup
+	var fastEvents []model.OrderStatusEventPayload
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			e, ok := <-fast
+			if !ok {
+				return
+			}
+			fastEvents = append(fastEvents, e)
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		b.Publish("order-1", model.OrderStatusEventPayload{OrderStatusID: "order-1"})
+		// deja que el suscriptor rápido consuma antes del siguiente
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	// el lento recibe lo que entró en su buffer y después el canal cerrado
+	events, closed := receiveAll(slow, 100*time.Millisecond)
+	if !closed || len(events) != 2 {
+		t.Fatalf("slow subscriber got %d events, closed %v; want its 2 buffered events and a closed channel", len(events), closed)
+	}
+	if len(fastEvents) != 5 {
+		t.Errorf("fast subscriber got %d events, want 5", len(fastEvents))
+	}
+	if n := b.Subscribers("order-1"); n != 1 {
+		t.Errorf("Subscribers() = %d, want only the fast one", n)
+	}
+}
+
+func TestStatusBrokerUnsubscribe(t *testing.T) {
+	b := NewStatusBroker()
+	ch, unsubscribe := b.Subscribe("order-1")
+	unsubscribe()
+	unsubscribe() // idempotente
+
+	if _, closed := receiveAll(ch, 50*time.Millisecond); !closed {
+		t.Fatalf("channel still open after unsubscribe")
+	}
+	if n := b.Subscribers("order-1"); n != 0 {
+		t.Errorf("Subscribers() = %d, want 0", n)
+	}
+	b.Publish("order-1", model.OrderStatusEventPayload{}) // sin suscriptores no hace nada
+}
+
+func TestStatusBrokerClose(t *testing.T) {
+	b := NewStatusBroker()
+	first, unsubscribe := b.Subscribe("order-1")
+	second, _ := b.Subscribe("order-2")
+
+	b.Close()
+	for i, ch := range []<-chan model.OrderStatusEventPayload{first, second} {
+		if _, closed := receiveAll(ch, 50*time.Millisecond); !closed {
+			t.Fatalf("subscriber %d still open after Close", i)
+		}
+	}
+	unsubscribe() // tras Close no debe cerrar el canal de nuevo
+
+	late, _ := b.Subscribe("order-1")
+	if _, closed := receiveAll(late, 50*time.Millisecond); !closed {
+		t.Errorf("Subscribe after Close returned an open channel")
+	}
+	b.Publish("order-1", model.OrderStatusEventPayload{})
+}
@@ -0,0 +1,117 @@
+// token_cache.go
+package service
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Contadores de la caché de tokens
+type TokenCacheStats struct {
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+	Size     int   `json:"size"`
+	Capacity int   `json:"capacity"`
+}
+
+// Resultado cacheado de validar un token: el usuario o el error definitivo
+type tokenCacheEntry struct {
+	key       [sha256.Size]byte
+	user      *AuthUser
+	err       error
+	expiresAt time.Time
+}
+
+// tokenCache es una caché LRU acotada de validaciones de tokens.
+// Las claves son el hash del token, para no guardar tokens en memoria.
+type tokenCache struct {
+	capacity int
+
+	mu    sync.Mutex
+	order *list.List // el más reciente al frente
+	items map[[sha256.Size]byte]*list.Element
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newTokenCache(capacity int) *tokenCache {
+	return &tokenCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    map[[sha256.Size]byte]*list.Element{},
+	}
+}
+
+func tokenKey(token string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(token))
+}
+
+func (c *tokenCache) get(key [sha256.Size]byte) (*tokenCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	entry := el.Value.(*tokenCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.hits.Add(1)
+	return entry, true
+}
+
+func (c *tokenCache) add(key [sha256.Size]byte, user *AuthUser, err error, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	entry := &tokenCacheEntry{key: key, user: user, err: err, expiresAt: time.Now().Add(ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*tokenCacheEntry).key)
+	}
+}
+
+func (c *tokenCache) flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.order.Len()
+	c.order.Init()
+	c.items = map[[sha256.Size]byte]*list.Element{}
+	return n
+}
+
+func (c *tokenCache) stats() TokenCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return TokenCacheStats{
+		Hits:     c.hits.Load(),
+		Misses:   c.misses.Load(),
+		Size:     size,
+		Capacity: c.capacity,
+	}
+}
@@ -0,0 +1,215 @@
+// token_cache_test.go
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"order-status-service/internal/config"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newTokenCache(2)
+	a, b, d := tokenKey("a"), tokenKey("b"), tokenKey("d")
+	c.add(a, &AuthUser{ID: "a"}, nil, time.Minute)
+	c.add(b, &AuthUser{ID: "b"}, nil, time.Minute)
+
+	// leer "a" lo vuelve el más reciente: al agregar "d" sale "b"
+	if _, ok := c.get(a); !ok {
+		t.Fatalf("get(a) missed, want a hit")
+	}
+	c.add(d, &AuthUser{ID: "d"}, nil, time.Minute)
+
+	for _, tt := range []struct {
+		name string
+		key  [sha256.Size]byte
+		want bool
+	}{
+		{"a", a, true},
+		{"b", b, false},
+		{"d", d, true},
+	} {
+		if _, ok := c.get(tt.key); ok != tt.want {
+			t.Errorf("get(%s) hit = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+	if size := c.stats().Size; size != 2 {
+		t.Errorf("Size = %d, want the capacity (2)", size)
+	}
+}
+
+func TestTokenCacheTTL(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+	c := newTokenCache(10)
+	valid, rejected := tokenKey("valid"), tokenKey("rejected")
+	c.add(valid, &AuthUser{ID: "user-1"}, nil, ttl)
+	c.add(rejected, nil, ErrInvalidToken, ttl)
+	c.add(tokenKey("no ttl"), &AuthUser{ID: "user-2"}, nil, 0)
+
+	if entry, ok := c.get(valid); !ok || entry.user.ID != "user-1" {
+		t.Fatalf("get(valid) = %+v, %v; want user-1", entry, ok)
+	}
+	if entry, ok := c.get(rejected); !ok || !errors.Is(entry.err, ErrInvalidToken) {
+		t.Fatalf("get(rejected) = %+v, %v; want ErrInvalidToken", entry, ok)
+	}
+	if size := c.stats().Size; size != 2 {
+		t.Fatalf("Size = %d, want 2 (an entry without ttl is not stored)", size)
+	}
+
+	time.Sleep(ttl)
+	for name, key := range map[string][sha256.Size]byte{"valid": valid, "rejected": rejected} {
+		if _, ok := c.get(key); ok {
+			t.Errorf("get(%s) after the ttl hit, want a miss", name)
+		}
+	}
+	if size := c.stats().Size; size != 0 {
+		t.Errorf("Size = %d, want expired entries removed", size)
+	}
+}
+
+func TestTokenCacheStatsAndFlush(t *testing.T) {
+	c := newTokenCache(10)
+	for _, token := range []string{"a", "b", "d"} {
+		c.add(tokenKey(token), &AuthUser{ID: token}, nil, time.Minute)
+	}
+	c.get(tokenKey("a"))
+	c.get(tokenKey("b"))
+	c.get(tokenKey("unknown"))
+
+	want := TokenCacheStats{Hits: 2, Misses: 1, Size: 3, Capacity: 10}
+	if got := c.stats(); got != want {
+		t.Fatalf("stats() = %+v, want %+v", got, want)
+	}
+
+	if n := c.flush(); n != 3 {
+		t.Errorf("flush() = %d, want 3", n)
+	}
+	if n := c.flush(); n != 0 {
+		t.Errorf("second flush() = %d, want 0", n)
+	}
+	if _, ok := c.get(tokenKey("a")); ok {
+		t.Errorf("get(a) after flush hit, want a miss")
+	}
+	// vaciar la caché no reinicia los contadores
+	want = TokenCacheStats{Hits: 2, Misses: 2, Size: 0, Capacity: 10}
+	if got := c.stats(); got != want {
+		t.Errorf("stats() after flush = %+v, want %+v", got, want)
+	}
+}
+
+func newCachedAuthService(url string, ttl, negativeTTL time.Duration) *AuthService {
+	return NewAuthService(config.AuthConfig{
+		URL:              url,
+		Mode:             AuthModeRemote,
+		Timeout:          time.Second,
+		CacheSize:        10,
+		CacheTTL:         ttl,
+		CacheNegativeTTL: negativeTTL,
+	})
+}
+
+func TestAuthServiceCollapsesConcurrentValidations(t *testing.T) {
+	const lookups = 50
+	fake, srv := newFakeAuth(t, http.StatusOK)
+	fake.hold = make(chan struct{})
+	a := newCachedAuthService(srv.URL, time.Minute, time.Minute)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, lookups)
+	for i := 0; i < lookups; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := a.ValidateToken(context.Background(), "token-1")
+			if err == nil && user.ID != "user-1" {
+				err = errors.New("unexpected user " + user.ID)
+			}
+			errs <- err
+		}()
+	}
+	// deja que todas las validaciones lleguen a esperar la misma llamada
+	time.Sleep(50 * time.Millisecond)
+	close(fake.hold)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("ValidateToken() = %v, want nil", err)
+		}
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("auth service got %d requests for %d concurrent lookups, want 1", calls, lookups)
+	}
+
+	// ya cacheado: no vuelve a llamar
+	if _, err := a.ValidateToken(context.Background(), "token-1"); err != nil {
+		t.Fatalf("ValidateToken() = %v, want nil", err)
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Errorf("auth service got %d requests after caching, want 1", calls)
+	}
+	if stats := a.CacheStats(); stats.Hits < 1 || stats.Size != 1 {
+		t.Errorf("CacheStats() = %+v, want at least 1 hit and 1 entry", stats)
+	}
+}
+
+func TestAuthServiceCacheTTL(t *testing.T) {
+	const ttl = 30 * time.Millisecond
+	tests := []struct {
+		name   string
+		status int
+		// llamadas al servicio tras dos validaciones seguidas y tras otra pasado el ttl
+		wantCalls [2]int32
+	}{
+		{"valid token", http.StatusOK, [2]int32{1, 2}},
+		{"rejected token", http.StatusUnauthorized, [2]int32{1, 2}},
+		// una falla del servicio no se cachea
+		{"auth service down", http.StatusServiceUnavailable, [2]int32{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, srv := newFakeAuth(t, tt.status)
+			a := newCachedAuthService(srv.URL, ttl, ttl)
+
+			for i := 0; i < 2; i++ {
+				a.ValidateToken(context.Background(), "token-1")
+			}
+			if calls := fake.calls.Load(); calls != tt.wantCalls[0] {
+				t.Fatalf("auth service got %d requests, want %d", calls, tt.wantCalls[0])
+			}
+			time.Sleep(ttl)
+			_, err := a.ValidateToken(context.Background(), "token-1")
+			if calls := fake.calls.Load(); calls != tt.wantCalls[1] {
+				t.Errorf("auth service got %d requests after the ttl, want %d", calls, tt.wantCalls[1])
+			}
+			if tt.status == http.StatusUnauthorized && !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("ValidateToken() = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestAuthServiceFlushCache(t *testing.T) {
+	fake, srv := newFakeAuth(t, http.StatusOK)
+	a := newCachedAuthService(srv.URL, time.Minute, time.Minute)
+
+	for _, token := range []string{"token-1", "token-2", "token-1"} {
+		if _, err := a.ValidateToken(context.Background(), token); err != nil {
+			t.Fatalf("ValidateToken(%s) = %v, want nil", token, err)
+		}
+	}
+	if n := a.FlushCache(); n != 2 {
+		t.Errorf("FlushCache() = %d, want 2", n)
+	}
+	if _, err := a.ValidateToken(context.Background(), "token-1"); err != nil {
+		t.Fatalf("ValidateToken() = %v, want nil", err)
+	}
+	if calls := fake.calls.Load(); calls != 3 {
+		t.Errorf("auth service got %d requests, want 3 (one per token, one more after the flush)", calls)
+	}
+}
@@ -0,0 +1,394 @@
+// webhook_service.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"order-status-service/internal/config"
+	"order-status-service/internal/dto"
+	"order-status-service/internal/model"
+	"order-status-service/internal/repository"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Cabeceras de las entregas de webhooks
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// WebhookStore guarda las suscripciones y sus entregas (lo implementa repository.WebhookRepository).
+// Las búsquedas por id y ClaimNextDelivery devuelven mongo.ErrNoDocuments si no hay resultado.
+type WebhookStore interface {
+	InsertSubscription(ctx context.Context, sub model.WebhookSubscription) error
+	FindSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	FindSubscriptionByID(ctx context.Context, id primitive.ObjectID) (model.WebhookSubscription, error)
+	FindActiveFor(ctx context.Context, statusID primitive.ObjectID, event string) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id primitive.ObjectID) error
+	InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	FindDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status model.DeliveryStatus, limit int64) ([]model.WebhookDelivery, error)
+	ClaimNextDelivery(ctx context.Context, lease time.Duration) (model.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time) error
+	ResetDelivery(ctx context.Context, id primitive.ObjectID) error
+}
+
+// WebhookService administra las suscripciones y entrega los eventos de cambio de estado
+type WebhookService struct {
+	repo     WebhookStore
+	catalog  *repository.CatalogRepository
+	client   *http.Client
+	wake     chan struct{}
+	timeouts config.TimeoutsConfig
+
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+func NewWebhookService(repo WebhookStore, catalog *repository.CatalogRepository, cfg config.WebhookConfig, timeouts config.TimeoutsConfig) *WebhookService {
+	return &WebhookService{
+		repo:         repo,
+		catalog:      catalog,
+		client:       &http.Client{Timeout: cfg.Timeout},
+		wake:         make(chan struct{}, 1),
+		timeouts:     timeouts,
+		PollInterval: cfg.PollInterval,
+		Lease:        cfg.Lease,
+		MaxAttempts:  cfg.MaxAttempts,
+		BaseBackoff:  cfg.BaseBackoff,
+		MaxBackoff:   cfg.MaxBackoff,
+	}
+}
+
+// Registra una nueva suscripción (solo admins)
+func (s *WebhookService) CreateSubscription(ctx context.Context, req dto.CreateWebhookRequest) (dto.CreateWebhookResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return dto.CreateWebhookResponse{}, NewError(ErrInvalidInput, "webhook.invalid_url")
+	}
+
+	statusIDs := make([]primitive.ObjectID, 0, len(req.StatusIDs))
+	for _, id := range req.StatusIDs {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return dto.CreateWebhookResponse{}, NewError(ErrInvalidInput, "status.invalid_id_value", id)
+		}
+		exists, err := s.catalog.ExistsByID(ctx, objID)
+		if err != nil {
+			return dto.CreateWebhookResponse{}, err
+		}
+		if !exists {
+			return dto.CreateWebhookResponse{}, NewError(ErrInvalidInput, "status.id_not_in_catalog", id)
+		}
+		statusIDs = append(statusIDs, objID)
+	}
+
+	events := []string{model.EventStatusChanged}
+	if len(req.Events) > 0 {
+		events = make([]string, 0, len(req.Events))
+		for _, event := range req.Events {
+			if !slices.Contains(model.WebhookEvents, event) {
+				return dto.CreateWebhookResponse{}, NewError(ErrInvalidInput, "webhook.invalid_event", event)
+			}
+			if !slices.Contains(events, event) {
+				events = append(events, event)
+			}
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return dto.CreateWebhookResponse{}, err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	sub := model.WebhookSubscription{
+		ID:        primitive.NewObjectID(),
+		URL:       u.String(),
+		Secret:    secret,
+		StatusIDs: statusIDs,
+		Events:    events,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.InsertSubscription(ctx, sub); err != nil {
+		return dto.CreateWebhookResponse{}, err
+	}
+
+	return dto.CreateWebhookResponse{
+		ID:        sub.ID.Hex(),
+		URL:       sub.URL,
+		Secret:    sub.Secret,
+		StatusIDs: req.StatusIDs,
+		Events:    sub.Events,
+		Active:    sub.Active,
+	}, nil
+}
+
+func (s *WebhookService) GetSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.repo.FindSubscriptions(ctx)
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (model.WebhookSubscription, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.WebhookSubscription{}, NewError(ErrInvalidInput, "webhook.invalid_id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	sub, err := s.repo.FindSubscriptionByID(ctx, objID)
+	if err != nil {
+		return model.WebhookSubscription{}, notFound(err, "webhook.not_found")
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return NewError(ErrInvalidInput, "webhook.invalid_id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+	if err := s.repo.DeleteSubscription(ctx, objID); err != nil {
+		return notFound(err, "webhook.not_found")
+	}
+	return nil
+}
+
+// Devuelve las últimas entregas de una suscripción para inspeccionar fallos
+func (s *WebhookService) GetDeliveries(ctx context.Context, id string, status string) ([]model.WebhookDelivery, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, NewError(ErrInvalidInput, "webhook.invalid_id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.repo.FindDeliveries(ctx, objID, model.DeliveryStatus(status), 100)
+}
+
+// Vuelve a encolar una entrega (p. ej. una fallida) para enviarla de inmediato
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID string) error {
+	objID, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return NewError(ErrInvalidInput, "delivery.invalid_id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+	if err := s.repo.ResetDelivery(ctx, objID); err != nil {
+		return notFound(err, "delivery.not_found")
+	}
+	s.notify()
+	return nil
+}
+
+// Publish crea una entrega por cada suscripción al tipo de evento interesada en el nuevo estado
+// (o, si cambió la dirección de envío, en el estado actual de la orden). Lo llama el relay
+// del outbox con cada evento confirmado; si devuelve error, el relay reintenta el evento y las
+// entregas ya creadas para él no se duplican. El envío lo hace el worker (Run).
+func (s *WebhookService) Publish(ctx context.Context, event model.OutboxEvent) error {
+	if event.Type != model.EventStatusChanged && event.Type != model.EventShippingUpdated {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	payload := event.Payload
+	statusID, err := primitive.ObjectIDFromHex(payload.StatusID)
+	if err != nil {
+		return nil
+	}
+	subs, err := s.repo.FindActiveFor(ctx, statusID, event.Type)
+	if err != nil {
+		return fmt.Errorf("webhooks: finding subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	deliveries := make([]model.WebhookDelivery, len(subs))
+	for i, sub := range subs {
+		deliveries[i] = model.WebhookDelivery{
+			ID:             primitive.NewObjectID(),
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			URL:            sub.URL,
+			Event:          event.Type,
+			Payload:        payload,
+			Status:         model.DeliveryPending,
+			Attempts:       []model.WebhookAttempt{},
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+	}
+	if err := s.repo.InsertDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("webhooks: storing deliveries: %w", err)
+	}
+	s.notify()
+	return nil
+}
+
+// Despierta al worker sin bloquear si ya tiene un aviso pendiente
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run envía las entregas pendientes hasta que se cancele el contexto
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *WebhookService) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		delivery, err := s.repo.ClaimNextDelivery(ctx, s.Lease)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return
+		}
+		if err != nil {
+			log.Printf("⚠️ Webhooks: error al leer entregas pendientes: %v", err)
+			return
+		}
+		s.attempt(ctx, delivery)
+	}
+}
+
+// Realiza un intento de entrega y registra su resultado
+func (s *WebhookService) attempt(ctx context.Context, delivery model.WebhookDelivery) {
+	sub, err := s.repo.FindSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil || !sub.Active {
+		// la suscripción fue eliminada o desactivada: no tiene sentido reintentar
+		attempt := model.WebhookAttempt{At: time.Now(), Error: "subscription not found or inactive"}
+		if err := s.repo.RecordAttempt(ctx, delivery.ID, attempt, model.DeliveryFailed, time.Now()); err != nil {
+			log.Printf("⚠️ Webhooks: error al registrar intento de %s: %v", delivery.ID.Hex(), err)
+		}
+		return
+	}
+
+	attempt := s.send(ctx, sub, delivery)
+
+	status := model.DeliverySucceeded
+	next := time.Now()
+	if attempt.Error != "" {
+		retries := delivery.Retries + 1
+		if retries >= s.MaxAttempts {
+			status = model.DeliveryFailed
+			log.Printf("❌ Webhooks: entrega %s a %s fallida tras %d intentos", delivery.ID.Hex(), sub.URL, retries)
+		} else {
+			status = model.DeliveryPending
+			next = next.Add(s.backoff(retries))
+		}
+	}
+
+	if err := s.repo.RecordAttempt(ctx, delivery.ID, attempt, status, next); err != nil {
+		log.Printf("⚠️ Webhooks: error al registrar intento de %s: %v", delivery.ID.Hex(), err)
+	}
+}
+
+// Envía la entrega firmada; cualquier respuesta fuera de 2xx cuenta como fallo
+func (s *WebhookService) send(ctx context.Context, sub model.WebhookSubscription, delivery model.WebhookDelivery) model.WebhookAttempt {
+	start := time.Now()
+	attempt := model.WebhookAttempt{At: start}
+
+	body, err := json.Marshal(map[string]any{
+		"id":         delivery.ID.Hex(),
+		"event":      delivery.Event,
+		"created_at": delivery.CreatedAt,
+		"data":       delivery.Payload,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// SignWebhook calcula la firma HMAC-SHA256 (hex) de "<timestamp>.<body>" con el secreto de la suscripción
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Espera antes del próximo intento: BaseBackoff * 2^(intentos-1), acotado por MaxBackoff
+func (s *WebhookService) backoff(retries int) time.Duration {
+	d := s.BaseBackoff
+	for i := 1; i < retries; i++ {
+		d *= 2
+		if d >= s.MaxBackoff {
+			return s.MaxBackoff
+		}
+	}
+	return d
+}
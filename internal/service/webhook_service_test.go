@@ -0,0 +1,433 @@
+// webhook_service_test.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"order-status-service/internal/config"
+	"order-status-service/internal/dto"
+	"order-status-service/internal/model"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Suscripciones y entregas en memoria, con la misma semántica que repository.WebhookRepository
+type memoryWebhooks struct {
+	mu            sync.Mutex
+	subscriptions []model.WebhookSubscription
+	deliveries    []model.WebhookDelivery
+}
+
+func (m *memoryWebhooks) InsertSubscription(ctx context.Context, sub model.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions = append(m.subscriptions, sub)
+	return nil
+}
+
+func (m *memoryWebhooks) FindSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]model.WebhookSubscription{}, m.subscriptions...), nil
+}
+
+func (m *memoryWebhooks) FindSubscriptionByID(ctx context.Context, id primitive.ObjectID) (model.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sub := range m.subscriptions {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+	return model.WebhookSubscription{}, mongo.ErrNoDocuments
+}
+
+func (m *memoryWebhooks) FindActiveFor(ctx context.Context, statusID primitive.ObjectID, event string) ([]model.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []model.WebhookSubscription
+	for _, sub := range m.subscriptions {
+		if !sub.Active || !slices.Contains(sub.Events, event) {
+			continue
+		}
+		for _, id := range sub.StatusIDs {
+			if id == statusID {
+				res = append(res, sub)
+				break
+			}
+		}
+		if len(sub.StatusIDs) == 0 {
+			res = append(res, sub)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryWebhooks) DeleteSubscription(ctx context.Context, id primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, sub := range m.subscriptions {
+		if sub.ID == id {
+			m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (m *memoryWebhooks) InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, deliveries...)
+	return nil
+}
+
+func (m *memoryWebhooks) FindDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status model.DeliveryStatus, limit int64) ([]model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []model.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && (status == "" || d.Status == status) {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryWebhooks) ClaimNextDelivery(ctx context.Context, lease time.Duration) (model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for i, d := range m.deliveries {
+		if d.Status == model.DeliveryPending && !d.NextAttemptAt.After(now) {
+			m.deliveries[i].NextAttemptAt = now.Add(lease)
+			return m.deliveries[i], nil
+		}
+	}
+	return model.WebhookDelivery{}, mongo.ErrNoDocuments
+}
+
+func (m *memoryWebhooks) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt model.WebhookAttempt, status model.DeliveryStatus, nextAttemptAt time.Time) error {
+	return m.update(id, func(d *model.WebhookDelivery) {
+		d.Status, d.NextAttemptAt, d.UpdatedAt = status, nextAttemptAt, time.Now()
+		d.Attempts = append(d.Attempts, attempt)
+		d.Retries++
+	})
+}
+
+func (m *memoryWebhooks) ResetDelivery(ctx context.Context, id primitive.ObjectID) error {
+	return m.update(id, func(d *model.WebhookDelivery) {
+		d.Status, d.Retries, d.NextAttemptAt = model.DeliveryPending, 0, time.Now()
+	})
+}
+
+func (m *memoryWebhooks) update(id primitive.ObjectID, fn func(d *model.WebhookDelivery)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == id {
+			fn(&m.deliveries[i])
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (m *memoryWebhooks) delivery(id primitive.ObjectID) model.WebhookDelivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.deliveries {
+		if d.ID == id {
+			return d
+		}
+	}
+	return model.WebhookDelivery{}
+}
+
+// Receptor de webhooks falso: responde con los códigos de statuses en orden (el último se repite)
+// y guarda cada petición recibida
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedWebhook
+	calls    atomic.Int32
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, *httptest.Server) {
+	rcv := &webhookReceiver{statuses: statuses}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		n := int(rcv.calls.Add(1))
+
+		rcv.mu.Lock()
+		rcv.requests = append(rcv.requests, receivedWebhook{header: r.Header.Clone(), body: body})
+		status := rcv.statuses[min(n, len(rcv.statuses))-1]
+		rcv.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return rcv, srv
+}
+
+func (rcv *webhookReceiver) last() receivedWebhook {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return rcv.requests[len(rcv.requests)-1]
+}
+
+const testWebhookSecret = "s3cret"
+
+func newTestWebhookService(store WebhookStore) *WebhookService {
+	return NewWebhookService(store, nil, config.WebhookConfig{
+		WorkerConfig: config.WorkerConfig{
+			PollInterval: time.Minute,
+			Lease:        time.Minute,
+			MaxAttempts:  3,
+			BaseBackoff:  time.Second,
+			MaxBackoff:   4 * time.Second,
+		},
+		Timeout: time.Second,
+	}, config.TimeoutsConfig{Read: time.Second, Write: time.Second})
+}
+
+// Registra una suscripción activa a url y una entrega pendiente para ella
+func seedDelivery(store *memoryWebhooks, url string, retries int) model.WebhookDelivery {
+	sub := model.WebhookSubscription{ID: primitive.NewObjectID(), URL: url, Secret: testWebhookSecret, Active: true}
+	store.InsertSubscription(context.Background(), sub)
+
+	now := time.Now()
+	delivery := model.WebhookDelivery{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: sub.ID,
+		EventID:        primitive.NewObjectID(),
+		URL:            url,
+		Event:          model.EventStatusChanged,
+		Payload:        model.OrderStatusEventPayload{OrderStatusID: "os-1", OrderID: "order-1", Status: "Enviado"},
+		Status:         model.DeliveryPending,
+		Attempts:       []model.WebhookAttempt{},
+		Retries:        retries,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	store.InsertDeliveries(context.Background(), []model.WebhookDelivery{delivery})
+	return delivery
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	rcv, srv := newWebhookReceiver(t, http.StatusOK)
+	store := &memoryWebhooks{}
+	delivery := seedDelivery(store, srv.URL, 0)
+
+	newTestWebhookService(store).drain(context.Background())
+
+	req := rcv.last()
+	timestamp := req.header.Get(WebhookTimestampHeader)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("%s = %q, want unix seconds", WebhookTimestampHeader, timestamp)
+	}
+	if got, want := req.header.Get(WebhookSignatureHeader), "sha256="+SignWebhook(testWebhookSecret, timestamp, req.body); got != want {
+		t.Errorf("%s = %q, want %q", WebhookSignatureHeader, got, want)
+	}
+	if got := req.header.Get(WebhookEventHeader); got != model.EventStatusChanged {
+		t.Errorf("%s = %q, want %q", WebhookEventHeader, got, model.EventStatusChanged)
+	}
+	if got := req.header.Get(WebhookDeliveryHeader); got != delivery.ID.Hex() {
+		t.Errorf("%s = %q, want %q", WebhookDeliveryHeader, got, delivery.ID.Hex())
+	}
+
+	var body struct {
+		ID    string                        `json:"id"`
+		Event string                        `json:"event"`
+		Data  model.OrderStatusEventPayload `json:"data"`
+	}
+	if err := json.Unmarshal(req.body, &body); err != nil {
+		t.Fatalf("invalid body %s: %v", req.body, err)
+	}
+	if body.ID != delivery.ID.Hex() || body.Event != model.EventStatusChanged || body.Data.OrderID != "order-1" {
+		t.Errorf("body = %+v, want the delivery id, event and payload", body)
+	}
+
+	// la firma no coincide con otro secreto ni con el cuerpo alterado
+	if SignWebhook("other", timestamp, req.body) == SignWebhook(testWebhookSecret, timestamp, req.body) {
+		t.Errorf("signature does not depend on the secret")
+	}
+	if SignWebhook(testWebhookSecret, timestamp, append(req.body, ' ')) == SignWebhook(testWebhookSecret, timestamp, req.body) {
+		t.Errorf("signature does not depend on the body")
+	}
+}
+
+func TestWebhookDeliveryRetriesThenSucceeds(t *testing.T) {
+	rcv, srv := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusOK)
+	store := &memoryWebhooks{}
+	delivery := seedDelivery(store, srv.URL, 0)
+	svc := newTestWebhookService(store)
+
+	before := time.Now()
+	svc.drain(context.Background())
+
+	got := store.delivery(delivery.ID)
+	if got.Status != model.DeliveryPending || got.Retries != 1 || len(got.Attempts) != 1 {
+		t.Fatalf("after a 500: status %s, retries %d, %d attempts; want pending with 1 attempt", got.Status, got.Retries, len(got.Attempts))
+	}
+	if a := got.Attempts[0]; a.StatusCode != http.StatusInternalServerError || a.Error == "" {
+		t.Errorf("attempt = %+v, want status 500 with an error", a)
+	}
+	if wait := got.NextAttemptAt.Sub(before); wait < time.Second || wait > 2*time.Second {
+		t.Errorf("next attempt in %s, want the base backoff (1s)", wait)
+	}
+
+	// todavía no vence: el worker no lo reintenta
+	svc.drain(context.Background())
+	if calls := rcv.calls.Load(); calls != 1 {
+		t.Fatalf("receiver got %d requests before the backoff elapsed, want 1", calls)
+	}
+
+	store.update(delivery.ID, func(d *model.WebhookDelivery) { d.NextAttemptAt = time.Now() })
+	svc.drain(context.Background())
+
+	got = store.delivery(delivery.ID)
+	if got.Status != model.DeliverySucceeded || got.Retries != 2 || len(got.Attempts) != 2 {
+		t.Fatalf("after a 200: status %s, retries %d, %d attempts; want succeeded with 2 attempts", got.Status, got.Retries, len(got.Attempts))
+	}
+	if a := got.Attempts[1]; a.StatusCode != http.StatusOK || a.Error != "" {
+		t.Errorf("attempt = %+v, want status 200 without error", a)
+	}
+}
+
+func TestWebhookDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	_, srv := newWebhookReceiver(t, http.StatusInternalServerError)
+	store := &memoryWebhooks{}
+	delivery := seedDelivery(store, srv.URL, 2)
+
+	newTestWebhookService(store).drain(context.Background())
+
+	got := store.delivery(delivery.ID)
+	if got.Status != model.DeliveryFailed || got.Retries != 3 || len(got.Attempts) != 1 {
+		t.Fatalf("status %s, retries %d, %d attempts; want failed after the 3rd attempt", got.Status, got.Retries, len(got.Attempts))
+	}
+}
+
+func TestWebhookDeliveryToInactiveSubscriptionFails(t *testing.T) {
+	rcv, srv := newWebhookReceiver(t, http.StatusOK)
+	store := &memoryWebhooks{}
+	delivery := seedDelivery(store, srv.URL, 0)
+	store.subscriptions[0].Active = false
+
+	newTestWebhookService(store).drain(context.Background())
+
+	if got := store.delivery(delivery.ID); got.Status != model.DeliveryFailed || len(got.Attempts) != 1 {
+		t.Fatalf("status %s, %d attempts; want failed without retries", got.Status, len(got.Attempts))
+	}
+	if calls := rcv.calls.Load(); calls != 0 {
+		t.Errorf("receiver got %d requests, want none", calls)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	svc := newTestWebhookService(&memoryWebhooks{})
+	for retries, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 5: 4 * time.Second} {
+		if got := svc.backoff(retries); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", retries, got, want)
+		}
+	}
+}
+
+func TestCreateSubscriptionEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []string
+		want    []string
+		wantErr error
+	}{
+		{"default", nil, []string{model.EventStatusChanged}, nil},
+		{"shipping only", []string{model.EventShippingUpdated}, []string{model.EventShippingUpdated}, nil},
+		{"both, repeated", []string{model.EventShippingUpdated, model.EventStatusChanged, model.EventShippingUpdated},
+			[]string{model.EventShippingUpdated, model.EventStatusChanged}, nil},
+		{"unknown event", []string{model.EventStatusChanged, "order.deleted"}, nil, ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryWebhooks{}
+			res, err := newTestWebhookService(store).CreateSubscription(context.Background(), dto.CreateWebhookRequest{
+				URL:    "https://partner.example.com/hooks",
+				Events: tt.events,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateSubscription() = %v, want %v", err, tt.wantErr)
+				}
+				if len(store.subscriptions) != 0 {
+					t.Errorf("stored %d subscriptions, want none", len(store.subscriptions))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateSubscription() = %v, want nil", err)
+			}
+			if !slices.Equal(res.Events, tt.want) || !slices.Equal(store.subscriptions[0].Events, tt.want) {
+				t.Errorf("events = %v (stored %v), want %v", res.Events, store.subscriptions[0].Events, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookPublishOnlyToSubscribedEvents(t *testing.T) {
+	statusID := primitive.NewObjectID()
+	subscription := func(active bool, events ...string) model.WebhookSubscription {
+		return model.WebhookSubscription{ID: primitive.NewObjectID(), URL: "https://partner.example.com", Events: events, Active: active}
+	}
+	statusOnly := subscription(true, model.EventStatusChanged)
+	shippingOnly := subscription(true, model.EventShippingUpdated)
+	both := subscription(true, model.EventStatusChanged, model.EventShippingUpdated)
+	inactive := subscription(false, model.EventStatusChanged, model.EventShippingUpdated)
+	store := &memoryWebhooks{subscriptions: []model.WebhookSubscription{statusOnly, shippingOnly, both, inactive}}
+	svc := newTestWebhookService(store)
+
+	tests := []struct {
+		event string
+		want  []primitive.ObjectID
+	}{
+		{model.EventStatusChanged, []primitive.ObjectID{statusOnly.ID, both.ID}},
+		{model.EventShippingUpdated, []primitive.ObjectID{shippingOnly.ID, both.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			store.deliveries = nil
+			event := model.OutboxEvent{
+				ID:      primitive.NewObjectID(),
+				Type:    tt.event,
+				Payload: model.OrderStatusEventPayload{OrderStatusID: "order-1", StatusID: statusID.Hex()},
+			}
+			if err := svc.Publish(context.Background(), event); err != nil {
+				t.Fatalf("Publish() = %v, want nil", err)
+			}
+			var got []primitive.ObjectID
+			for _, d := range store.deliveries {
+				if d.Event != tt.event {
+					t.Errorf("delivery event = %q, want %q", d.Event, tt.event)
+				}
+				got = append(got, d.SubscriptionID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("deliveries for subscriptions %v, want %v", got, tt.want)
+			}
+		})
+	}
+}